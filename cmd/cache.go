@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+)
+
+const (
+	CacheDirName = "cache"
+
+	CacheUseMessage        = "cache"
+	CacheShortMessage      = "manage the azabox cache"
+	CacheCleanUseMessage   = "clean"
+	CacheCleanShortMessage = "remove every cached response and download"
+	CachePruneUseMessage   = "prune"
+	CachePruneShortMessage = "remove the least recently used downloads until the cache fits its maximum size"
+)
+
+type CacheCommandConfig struct {
+	cacheDir      string
+	downloadCache *installer.DownloadCache
+	maxSize       int64
+}
+
+func newCacheCommand(cacheDir string, downloadCache *installer.DownloadCache, maxSize int64) *cobra.Command {
+	cfg := CacheCommandConfig{
+		cacheDir:      cacheDir,
+		downloadCache: downloadCache,
+		maxSize:       maxSize,
+	}
+
+	cmd := &cobra.Command{
+		Use:           CacheUseMessage,
+		Short:         CacheShortMessage,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   CacheCleanUseMessage,
+		Short: CacheCleanShortMessage,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeCacheCleanCommand(cfg)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	})
+
+	var pruneSize string
+	pruneCmd := &cobra.Command{
+		Use:   CachePruneUseMessage,
+		Short: CachePruneShortMessage,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pruneCfg := cfg
+			if pruneSize != "" {
+				size, err := config.ParseSize(pruneSize)
+				if err != nil {
+					return err
+				}
+				pruneCfg.maxSize = size
+			}
+			return executeCachePruneCommand(pruneCfg)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	pruneCmd.Flags().StringVar(&pruneSize, "max-size", "",
+		"size to prune the cache to (e.g. 512M, 2GiB), defaults to the configured cache max-size")
+	cmd.AddCommand(pruneCmd)
+
+	return cmd
+}
+
+func executeCacheCleanCommand(cfg CacheCommandConfig) error {
+	if _, err := os.Stat(cfg.cacheDir); errors.Is(err, os.ErrNotExist) {
+		fmt.Println("Cache is already empty")
+		return nil
+	}
+	if installer.DryRun {
+		fmt.Printf("Would remove %s\n", cfg.cacheDir)
+		return nil
+	}
+	if err := os.RemoveAll(cfg.cacheDir); err != nil {
+		return fmt.Errorf("cannot clean cache: %w", err)
+	}
+	fmt.Printf("Removed %s\n", cfg.cacheDir)
+	return nil
+}
+
+func executeCachePruneCommand(cfg CacheCommandConfig) error {
+	if installer.DryRun {
+		fmt.Printf("Would prune the cached downloads to %s\n", config.FormatSize(cfg.maxSize))
+		return nil
+	}
+	result, err := cfg.downloadCache.Prune(cfg.maxSize)
+	if err != nil {
+		return fmt.Errorf("cannot prune cache: %w", err)
+	}
+	fmt.Printf("Removed %d cached downloads, freed %s, cache size is %s (max %s)\n", result.Removed,
+		config.FormatSize(result.Freed), config.FormatSize(result.Size), config.FormatSize(cfg.maxSize))
+	return nil
+}
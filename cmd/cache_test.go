@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func TestNewCacheCommand(t *testing.T) {
+	t.Run("should create a new cache command", func(t *testing.T) {
+		cmd := newCacheCommand(t.TempDir(), installer.NewDownloadCache(t.TempDir(), 0), 0)
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, CacheUseMessage, cmd.Use)
+		assert.Equal(t, CacheShortMessage, cmd.Short)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+
+		clean, _, err := cmd.Find([]string{CacheCleanUseMessage})
+		require.NoError(t, err)
+		assert.Equal(t, CacheCleanShortMessage, clean.Short)
+		assert.NotNil(t, clean.RunE)
+		prune, _, err := cmd.Find([]string{CachePruneUseMessage})
+		require.NoError(t, err)
+		assert.Equal(t, CachePruneShortMessage, prune.Short)
+		assert.NotNil(t, prune.Flags().Lookup("max-size"))
+	})
+
+	t.Run("should reject an invalid prune size", func(t *testing.T) {
+		cmd := newCacheCommand(t.TempDir(), installer.NewDownloadCache(t.TempDir(), 0), 0)
+		prune, _, err := cmd.Find([]string{CachePruneUseMessage})
+		require.NoError(t, err)
+		require.NoError(t, prune.Flags().Set("max-size", "lots"))
+
+		err = prune.RunE(prune, []string{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid size")
+	})
+}
+
+func TestExecuteCacheCleanCommand(t *testing.T) {
+	t.Run("should remove the cache directory", func(t *testing.T) {
+		cacheDir := filepath.Join(t.TempDir(), CacheDirName)
+		require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "http"), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "http", "entry.json"), []byte("{}"), 0o600))
+
+		err := executeCacheCleanCommand(CacheCommandConfig{cacheDir: cacheDir})
+
+		require.NoError(t, err)
+		assert.NoDirExists(t, cacheDir)
+	})
+
+	t.Run("should handle missing cache directory", func(t *testing.T) {
+		err := executeCacheCleanCommand(CacheCommandConfig{cacheDir: filepath.Join(t.TempDir(), CacheDirName)})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestExecuteCachePruneCommand(t *testing.T) {
+	t.Run("should prune the cached downloads to the maximum size", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		cacheDir := t.TempDir()
+		downloadCache := installer.NewDownloadCache(cacheDir, 0)
+		asset := filepath.Join(t.TempDir(), "tool")
+		require.NoError(t, os.WriteFile(asset, []byte("binary data"), 0o600))
+		entry := installer.CachedDownload{
+			URL:    "https://foo.bar/tool",
+			SHA256: "9cb63cb779e8c571db3199b783a36cc43cd9e7c076beeb496c39e9cc06196dc5",
+		}
+		require.NoError(t, downloadCache.Store(entry, asset))
+
+		err := executeCachePruneCommand(CacheCommandConfig{cacheDir: cacheDir, downloadCache: downloadCache})
+
+		require.NoError(t, err)
+		_, ok := downloadCache.Lookup(entry.URL)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/project"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	ExecUseMessage   = "exec"
+	ExecShortMessage = "run a binary in the version set by <binary>@<version>, its environment variable " +
+		"or the closest " + project.FileName + " file"
+
+	ExecArgsCountErrorMessage = "exec need a binary to run, see above usage"
+)
+
+// execBinary replaces the azabox process with the binary, tests stub it
+var execBinary = syscall.Exec
+
+type ExecCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+}
+
+func newExecCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
+	cfg := ExecCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   ExecUseMessage,
+		Short: ExecShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				_ = cmd.Help()
+				return errors.New(ExecArgsCountErrorMessage)
+			}
+			binaryArgs := args[1:]
+			if len(binaryArgs) > 0 && binaryArgs[0] == "--" {
+				binaryArgs = binaryArgs[1:]
+			}
+			return executeExecCommand(cmd.Context(), cfg, args[0], binaryArgs...)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	// the flags after the binary name belong to the binary
+	cmd.Flags().SetInterspersed(false)
+
+	return cmd
+}
+
+func executeExecCommand(ctx context.Context, cfg ExecCommandConfig, arg string, args ...string) error {
+	binaryName, version, err := parseBinaryArg(arg)
+	if err != nil {
+		return err
+	}
+	// running a binary only reads the state, it must not wait for an install to release the lock
+	err = cfg.azaState.Peek()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
+	if !ok {
+		return fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName)
+	}
+	if version == "" {
+		return execSelectedVersion(cfg, binaryInfo, args)
+	}
+
+	version = trackedVersion(binaryInfo, version)
+	if _, tracked := binaryInfo.FindVersion(version); !tracked || !cfg.azaInstaller.IsInstalled(&binaryInfo, version) {
+		if binaryInfo, version, err = ensureVersion(ctx, cfg, binaryInfo.FullName, version); err != nil {
+			return err
+		}
+	}
+	if installer.DryRun {
+		fmt.Fprintf(os.Stderr, "Would run version %s of %s\n", version, binaryInfo.DisplayName())
+		return nil
+	}
+
+	path := cfg.azaInstaller.BinaryPath(&binaryInfo, version)
+	logging.Logger().Debug("exec binary", "name", binaryInfo.DisplayName(), "version", version, "path", path)
+	return execBinary(path, append([]string{binaryInfo.Name}, args...), os.Environ())
+}
+
+// ensureVersion installs a version of the binary for a one-off run, it is tracked in the state
+// but the active version and its link are kept, the progress goes to stderr to keep stdout to the binary
+func ensureVersion(ctx context.Context, cfg ExecCommandConfig, fullName, version string) (
+	dto.BinaryInfo, string, error,
+) {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return dto.BinaryInfo{}, "", err
+	}
+	defer cfg.azaState.Unlock()
+
+	binaryInfo, ok := cfg.azaState.Entry(fullName)
+	if !ok {
+		return dto.BinaryInfo{}, "", fmt.Errorf("binary %s is not installed (or not managed by azabox)", fullName)
+	}
+	installedVersion, err := installVersion(ctx, os.Stderr, &binaryInfo, version, cfg.azaInstaller)
+	if err != nil {
+		return dto.BinaryInfo{}, "", err
+	}
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	return binaryInfo, installedVersion, cfg.azaState.Save()
+}
+
+// executeShim runs the binary a shim was invoked for, it reports false when name is not a managed binary,
+// e.g. a renamed azabox executable
+func executeShim(cfg ExecCommandConfig, name string, args []string) (bool, error) {
+	err := cfg.azaState.Peek()
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("cannot run %s: %w", name, err)
+	}
+	for _, binaryInfo := range cfg.azaState.Entries() {
+		if binaryInfo.Name == name {
+			if err := logging.InitLogger(); err != nil {
+				return true, err
+			}
+			return true, execSelectedVersion(cfg, binaryInfo, args)
+		}
+	}
+	return false, nil
+}
+
+func execSelectedVersion(cfg ExecCommandConfig, binaryInfo dto.BinaryInfo, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	version, origin, err := selectVersion(cwd, binaryInfo)
+	if err != nil {
+		return err
+	}
+	if !cfg.azaInstaller.IsInstalled(&binaryInfo, version) {
+		// exec with the version installs it without switching the active one as use would
+		return fmt.Errorf("version %s of %s (set by %s) is not installed, install and run it with: azabox exec %s@%s",
+			version, binaryInfo.DisplayName(), origin, binaryInfo.Name, version)
+	}
+
+	path := cfg.azaInstaller.BinaryPath(&binaryInfo, version)
+	logging.Logger().Debug("exec binary", "name", binaryInfo.DisplayName(), "version", version,
+		"origin", origin, "path", path)
+	return execBinary(path, append([]string{binaryInfo.Name}, args...), os.Environ())
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// versionEnvName is the environment variable overriding the version of a binary, e.g. AZABOX_HELM_DOCS_VERSION
+func versionEnvName(name string) string {
+	return "AZABOX_" + strings.ToUpper(nonAlphanumeric.ReplaceAllString(name, "_")) + "_VERSION"
+}
+
+// selectVersion returns the version of the binary set by its environment variable, pinned by the
+// closest project file of dir or the active version otherwise, along with where it comes from
+func selectVersion(dir string, binaryInfo dto.BinaryInfo) (string, string, error) {
+	envName := versionEnvName(binaryInfo.Name)
+	if version := os.Getenv(envName); version != "" {
+		return trackedVersion(binaryInfo, version), envName, nil
+	}
+	versions, err := project.Find(dir)
+	if err != nil {
+		return "", "", err
+	}
+	version, ok := versions.Version(binaryInfo.FullName)
+	if !ok {
+		return binaryInfo.ActiveVersion, "the active version", nil
+	}
+	return trackedVersion(binaryInfo, version), versions.Path, nil
+}
+
+// trackedVersion matches a version which may omit the "v" prefix with the tracked versions
+func trackedVersion(binaryInfo dto.BinaryInfo, version string) string {
+	for _, versionInfo := range binaryInfo.Versions {
+		if sameVersion(versionInfo.Version, version) {
+			return versionInfo.Version
+		}
+	}
+	return version
+}
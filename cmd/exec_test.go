@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/project"
+	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+)
+
+type execCall struct {
+	path string
+	args []string
+}
+
+// stubExec records the exec instead of replacing the test process
+func stubExec(t *testing.T) *execCall {
+	t.Helper()
+	call := &execCall{}
+	original := execBinary
+	execBinary = func(path string, args []string, _ []string) error {
+		call.path = path
+		call.args = args
+		return nil
+	}
+	t.Cleanup(func() { execBinary = original })
+	return call
+}
+
+// newExecTestState tracks a second version of the test binary next to its active one
+func newExecTestState() *DummyState {
+	binaryInfo := newTestBinary("v2.0.0")
+	binaryInfo.AddVersion(dto.VersionInfo{Version: "v1.0.0"})
+	return createFakeState([]dto.BinaryInfo{binaryInfo})
+}
+
+func TestNewExecCommand(t *testing.T) {
+	t.Run("should create a new exec command", func(t *testing.T) {
+		cmd := newExecCommand(&DummyInstaller{}, &DummyState{})
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, ExecUseMessage, cmd.Use)
+		assert.Equal(t, ExecShortMessage, cmd.Short)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+
+	t.Run("should return an error without binary", func(t *testing.T) {
+		cmd := newExecCommand(&DummyInstaller{}, &DummyState{})
+
+		err := cmd.RunE(cmd, []string{})
+
+		require.Error(t, err)
+		assert.Equal(t, ExecArgsCountErrorMessage, err.Error())
+	})
+
+	t.Run("should pass the arguments after -- to the binary", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v2.0.0"}}
+		cmd := newExecCommand(dummyInstaller, newExecTestState())
+		t.Chdir(t.TempDir())
+
+		err := cmd.RunE(cmd, []string{TestBinaryName, "--", "--version"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{TestBinaryName, "--version"}, call.args)
+	})
+}
+
+func TestExecuteExecCommand(t *testing.T) {
+	t.Run("should run the active version outside a project", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v2.0.0"}}
+		cfg := ExecCommandConfig{azaInstaller: dummyInstaller, azaState: newExecTestState()}
+		t.Chdir(t.TempDir())
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName, "version")
+
+		require.NoError(t, err)
+		assert.Equal(t, "/fake/bin/foo-v2.0.0", call.path)
+		assert.Equal(t, []string{TestBinaryName, "version"}, call.args)
+	})
+
+	t.Run("should run the version pinned by the project", func(t *testing.T) {
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v1.0.0", "v2.0.0"}}
+		dummyState := newExecTestState()
+		cfg := ExecCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, project.FileName), []byte("foo 1.0.0\n"), 0o600))
+		dir := filepath.Join(root, "sub")
+		require.NoError(t, os.Mkdir(dir, 0o750))
+		t.Chdir(dir)
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName)
+
+		require.NoError(t, err)
+		assert.Equal(t, "/fake/bin/foo-v1.0.0", call.path)
+		assert.Equal(t, 1, dummyState.loadCount)
+		assert.Equal(t, 0, dummyState.saveCount, "state should not be written")
+	})
+
+	t.Run("should handle pinned version not installed", func(t *testing.T) {
+		call := stubExec(t)
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: newExecTestState()}
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, project.FileName), []byte("foo v3.0.0\n"), 0o600))
+		t.Chdir(dir)
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "version v3.0.0 of foo (set by "+filepath.Join(dir, project.FileName)+
+			") is not installed")
+		assert.Contains(t, err.Error(), "azabox exec foo@v3.0.0")
+		assert.NotContains(t, err.Error(), "azabox use", "the active version should not be switched")
+		assert.Empty(t, call.path)
+	})
+
+	t.Run("should handle binary not in state", func(t *testing.T) {
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: newExecTestState()}
+
+		err := executeExecCommand(t.Context(), cfg, "unknown")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
+	})
+
+	t.Run("should refuse a missing version after @", func(t *testing.T) {
+		call := stubExec(t)
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: newExecTestState()}
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName+"@")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing version after @")
+		assert.Empty(t, call.path, "no binary should be run")
+	})
+
+	t.Run("should run an installed version given with the binary", func(t *testing.T) {
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v1.0.0", "v2.0.0"}}
+		dummyState := newExecTestState()
+		cfg := ExecCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName+"@1.0.0", "version")
+
+		require.NoError(t, err)
+		assert.Equal(t, "/fake/bin/foo-v1.0.0", call.path)
+		assert.Equal(t, []string{TestBinaryName, "version"}, call.args)
+		assert.Equal(t, 0, dummyInstaller.installCount)
+		assert.Equal(t, 0, dummyState.saveCount, "state should not be written")
+	})
+
+	t.Run("should install a missing version without changing the active one", func(t *testing.T) {
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v2.0.0"}}
+		dummyState := newExecTestState()
+		cfg := ExecCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName+"@"+TestBinaryVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, "/fake/bin/foo-"+TestBinaryVersion, call.path)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		assert.Equal(t, 0, dummyInstaller.linkCount, "active link should be left untouched")
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, "v2.0.0", info.ActiveVersion)
+		_, tracked := info.FindVersion(TestBinaryVersion)
+		assert.True(t, tracked)
+		assert.Equal(t, 1, dummyState.saveCount)
+	})
+
+	t.Run("should not run the binary when the install failed", func(t *testing.T) {
+		dummyResolver := &DummyResolver{onError: true}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		call := stubExec(t)
+		dummyState := newExecTestState()
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: dummyState}
+
+		err := executeExecCommand(t.Context(), cfg, TestBinaryName+"@v3.0.0")
+
+		require.Error(t, err)
+		assert.Empty(t, call.path)
+		assert.Equal(t, 0, dummyState.saveCount)
+	})
+}
+
+func TestSelectVersion(t *testing.T) {
+	t.Run("should prefer the environment variable to the project file", func(t *testing.T) {
+		binaryInfo, _ := newExecTestState().Entry(TestBinaryFullName)
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, project.FileName), []byte("foo v2.0.0\n"), 0o600))
+		t.Setenv("AZABOX_FOO_VERSION", "1.0.0")
+
+		version, origin, err := selectVersion(dir, binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", version)
+		assert.Equal(t, "AZABOX_FOO_VERSION", origin)
+	})
+
+	t.Run("should name the environment variable after the binary", func(t *testing.T) {
+		assert.Equal(t, "AZABOX_HELM_DOCS_VERSION", versionEnvName("helm-docs"))
+	})
+}
+
+func TestExecuteShim(t *testing.T) {
+	t.Run("should run the binary the shim was invoked for", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		call := stubExec(t)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"v1.0.0", "v2.0.0"}}
+		cfg := ExecCommandConfig{azaInstaller: dummyInstaller, azaState: newExecTestState()}
+		t.Chdir(t.TempDir())
+		t.Setenv("AZABOX_FOO_VERSION", "v1.0.0")
+
+		handled, err := executeShim(cfg, TestBinaryName, []string{"--help"})
+
+		require.NoError(t, err)
+		assert.True(t, handled)
+		assert.Equal(t, "/fake/bin/foo-v1.0.0", call.path)
+		assert.Equal(t, []string{TestBinaryName, "--help"}, call.args)
+	})
+
+	t.Run("should not handle unknown binary", func(t *testing.T) {
+		call := stubExec(t)
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: newExecTestState()}
+
+		handled, err := executeShim(cfg, "cmd.test", nil)
+
+		require.NoError(t, err)
+		assert.False(t, handled)
+		assert.Empty(t, call.path)
+	})
+
+	t.Run("should report state error instead of running azabox commands", func(t *testing.T) {
+		call := stubExec(t)
+		cfg := ExecCommandConfig{azaInstaller: &DummyInstaller{}, azaState: &DummyState{onError: true}}
+
+		handled, err := executeShim(cfg, TestBinaryName, []string{"get", "pods"})
+
+		require.Error(t, err)
+		assert.True(t, handled)
+		assert.Contains(t, err.Error(), DummyStateErrorMessage)
+		assert.Empty(t, call.path)
+	})
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// OutdatedExitCode is returned when at least one binary is behind its latest version
+	OutdatedExitCode = 2
+	// PartialFailureExitCode is returned when a command failed on some of the binaries only
+	PartialFailureExitCode = 3
+)
+
+// ExitError makes the process exit with a specific code, Err is reported when set
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// failureReport collects the binaries a multi binary command failed on,
+// so that every binary is attempted and the failures are reported at the end
+type failureReport struct {
+	action   string
+	total    int
+	failures []string
+	lastErr  error
+}
+
+func newFailureReport(action string, total int) *failureReport {
+	return &failureReport{action: action, total: total}
+}
+
+func (r *failureReport) add(name string, err error) {
+	r.lastErr = err
+	r.failures = append(r.failures,
+		fmt.Sprintf("  - %s: %s", name, strings.ReplaceAll(err.Error(), "\n", "\n    ")))
+}
+
+// err returns nil when every binary succeeded, the error alone for a single binary
+// and a summary otherwise, exiting with PartialFailureExitCode when some binaries succeeded
+func (r *failureReport) err() error {
+	switch {
+	case len(r.failures) == 0:
+		return nil
+	case r.total == 1:
+		return r.lastErr
+	}
+
+	err := fmt.Errorf("%d of %d %s failed:\n%s", len(r.failures), r.total, r.action,
+		strings.Join(r.failures, "\n"))
+	if len(r.failures) < r.total {
+		return &ExitError{Code: PartialFailureExitCode, Err: err}
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -8,9 +9,12 @@ import (
 
 	"github.com/spf13/cobra"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
 	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/manifest"
 	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+	"gitlab.com/ludovic-alarcon/azabox/internal/semver"
 	"gitlab.com/ludovic-alarcon/azabox/internal/state"
 )
 
@@ -20,7 +24,10 @@ const (
 
 	DefaultBinaryVersion = "latest"
 
-	ArgsCountErrorMessage = "install need at least one argument, see above usage"
+	ArgsCountErrorMessage           = "install need at least one argument, see above usage"
+	LatestUrlWithoutUrlErrorMessage = "--latest-url can only be used with --url"
+	FrozenFlagsErrorMessage         = "--frozen installs the lockfile versions, it cannot be used with --version, " +
+		"--source or --url"
 )
 
 type InstallCommandConfig struct {
@@ -33,14 +40,18 @@ func createBinaryInfo(binaryName, version string) dto.BinaryInfo {
 		FullName: binaryName,
 		Version:  version,
 	}
+	if semver.IsConstraint(version) {
+		binaryInfo.Constraint = version
+	}
 
-	info := strings.Split(binaryInfo.FullName, "/")
-	if len(info) == 1 {
+	// the owner can be a nested group path (group/subgroup/project), the name is always the last segment
+	idx := strings.LastIndex(binaryInfo.FullName, "/")
+	if idx < 0 {
 		binaryInfo.Name = binaryInfo.FullName
 		binaryInfo.Owner = binaryInfo.FullName
 	} else {
-		binaryInfo.Owner = info[0]
-		binaryInfo.Name = info[1]
+		binaryInfo.Owner = binaryInfo.FullName[:idx]
+		binaryInfo.Name = binaryInfo.FullName[idx+1:]
 	}
 
 	binaryInfo.FullName = fmt.Sprintf("%s/%s", binaryInfo.Owner, binaryInfo.Name)
@@ -59,49 +70,215 @@ func binariesInfoFromArgs(installArgs []string, version string) []dto.BinaryInfo
 	return binaryInfosSlice
 }
 
-func installBinary(binaryInfo *dto.BinaryInfo, cfg InstallCommandConfig) error {
+func installBinary(ctx context.Context, binaryInfo *dto.BinaryInfo, cfg InstallCommandConfig) error {
 	logging.Logger().Debug("Installing binary", "binary", binaryInfo.Name, "owner",
 		binaryInfo.Owner, "version", binaryInfo.Version)
 	fmt.Printf("Installing binary \"%s\" with version \"%s\"\n", binaryInfo.FullName, binaryInfo.Version)
 
+	if cfg.azaState.Has(binaryInfo.FullName) {
+		return errors.New("binary already installed, use update command to download newer version")
+	}
+
+	resolvedUrl, _, err := resolver.GetRegistryResolver().Resolve(ctx, binaryInfo)
+	if err != nil {
+		if errors.Is(err, resolver.ErrNotResolved) {
+			logging.Logger().Debug("Binary not found", "binary", binaryInfo.Name, "owner",
+				binaryInfo.Owner, "version", binaryInfo.Version)
+		}
+		return err
+	}
+
+	if err = cfg.azaInstaller.Install(ctx, os.Stdout, binaryInfo, resolvedUrl); err != nil {
+		return err
+	}
+
+	cfg.azaState.UpdateEntrie(*binaryInfo)
+	return nil
+}
+
+// executeInstallCommand attempts every binary, the ones installed are saved even if others failed
+func executeInstallCommand(ctx context.Context, cfg InstallCommandConfig, binariesInfo []dto.BinaryInfo) error {
 	err := cfg.azaState.Load()
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
+	defer cfg.azaState.Unlock()
 
-	if cfg.azaState.Has(binaryInfo.FullName) {
-		return errors.New("binary already installed, use update command to download newer version")
+	report := newFailureReport("installs", len(binariesInfo))
+	for _, binaryInfo := range binariesInfo {
+		if err := ctx.Err(); err != nil {
+			report.add(binaryInfo.DisplayName(), err)
+			continue
+		}
+		if err := installBinary(ctx, &binaryInfo, cfg); err != nil {
+			report.add(binaryInfo.DisplayName(), err)
+		}
+	}
+
+	if err := cfg.azaState.Save(); err != nil {
+		return err
+	}
+	return report.err()
+}
+
+// executeFrozenInstallCommand installs the locked binaries without resolving them, their assets
+// are downloaded from the locked urls, verified with the locked files and must match the locked digest
+func executeFrozenInstallCommand(ctx context.Context, cfg InstallCommandConfig, lockPath string,
+	binaryNames ...string,
+) error {
+	lock, err := manifest.LoadLock(lockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("lockfile %s not found, run the sync command to create it", lockPath)
+	}
+	if err != nil {
+		return err
+	}
+	tools := lock.Tools
+	if len(binaryNames) > 0 {
+		tools = make([]manifest.LockedTool, 0, len(binaryNames))
+		for _, binaryName := range binaryNames {
+			tool, ok := lock.Find(dto.NormalizeName(binaryName))
+			if !ok {
+				return fmt.Errorf("binary %s is not in %s", binaryName, lockPath)
+			}
+			tools = append(tools, tool)
+		}
+	}
+
+	err = cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
 	}
+	defer cfg.azaState.Unlock()
 
-	resolvers := resolver.GetRegistryResolver().GetResolvers()
-	resolvedUrl := ""
-	for resolver := range resolvers {
-		url, err := resolver.Resolve(binaryInfo)
-		if err == nil && url != "" {
-			resolvedUrl = url
-			logging.Logger().Debug("Matched resolver", "type",
-				fmt.Sprintf("%T", resolver), "url", url)
-			break
+	report := newFailureReport("installs", len(tools))
+	for _, tool := range tools {
+		if err := ctx.Err(); err != nil {
+			report.add(tool.Name, err)
+			continue
+		}
+		if err := installLocked(ctx, tool, cfg); err != nil {
+			report.add(tool.Name, err)
 		}
 	}
 
-	if resolvedUrl == "" {
-		logging.Logger().Debug("Binary not found", "binary", binaryInfo.Name, "owner",
-			binaryInfo.Owner, "version", binaryInfo.Version)
-		fmt.Printf("Binary \"%s\" with version \"%s\" not found\n", binaryInfo.FullName, binaryInfo.Version)
-		return cfg.azaState.Save()
+	if err := cfg.azaState.Save(); err != nil {
+		return err
 	}
+	return report.err()
+}
 
-	if err = cfg.azaInstaller.Install(binaryInfo, resolvedUrl); err != nil {
+func installLocked(ctx context.Context, tool manifest.LockedTool, cfg InstallCommandConfig) error {
+	binaryInfo := createBinaryInfo(tool.Name, tool.Version)
+	if entry, ok := cfg.azaState.Entry(binaryInfo.FullName); ok {
+		if entry.ActiveVersion == tool.Version && cfg.azaInstaller.IsInstalled(&entry, tool.Version) {
+			versionInfo, _ := entry.FindVersion(tool.Version)
+			if tool.SHA256 == "" || versionInfo.SHA256 == tool.SHA256 {
+				fmt.Printf("Binary %s already uses locked version %s\n", entry.DisplayName(), tool.Version)
+				return nil
+			}
+			// same version, different asset: reinstall it so the download is checked against the lock
+			fmt.Printf("Binary %s %s does not match the locked digest, reinstalling it\n", entry.DisplayName(),
+				tool.Version)
+		}
+		// the versions already installed stay tracked
+		binaryInfo = entry
+	}
+	binaryInfo.Resolver = tool.Resolver
+	restoreLockedResolution(&binaryInfo, tool)
+	binaryInfo.InstalledVersion = tool.Version
+	binaryInfo.LockedSHA256 = tool.SHA256
+	binaryInfo.ChecksumURL = tool.ChecksumURL
+	binaryInfo.SignatureURLs = tool.SignatureURLs
+	binaryInfo.AssetAPIURLs = lockedAssetAPIUrls(tool)
+	// like the resolver does, private release files are downloaded through the API with the token
+	if httpclient.GitHubToken() != "" {
+		for downloadUrl, apiUrl := range binaryInfo.AssetAPIURLs {
+			httpclient.RedirectAsset(downloadUrl, apiUrl)
+		}
+	}
+
+	logging.Logger().Debug("Installing locked binary", "binary", tool.Name, "version", tool.Version,
+		"url", tool.URL)
+	fmt.Printf("Installing binary \"%s\" with locked version \"%s\"\n", binaryInfo.FullName, tool.Version)
+	if err := cfg.azaInstaller.Install(ctx, os.Stdout, &binaryInfo, tool.URL); err != nil {
 		return err
 	}
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	return nil
+}
 
-	cfg.azaState.UpdateEntrie(*binaryInfo)
-	return cfg.azaState.Save()
+// restoreLockedResolution sets how the binary is resolved from the lock, so that a binary first installed
+// by a frozen install is updated the way sync would have installed it. A lockfile written before these
+// were recorded leaves the entry as it is
+func restoreLockedResolution(binaryInfo *dto.BinaryInfo, tool manifest.LockedTool) {
+	if tool.Constraint != "" {
+		// like an install given a constraint, it is kept as requested version
+		binaryInfo.Version = tool.Constraint
+		binaryInfo.Constraint = tool.Constraint
+	}
+	if tool.URLTemplate != "" {
+		binaryInfo.URLTemplate = tool.URLTemplate
+		binaryInfo.TemplateVars = tool.TemplateVars
+		binaryInfo.LatestVersionURL = tool.LatestVersionURL
+	}
+}
+
+// lockedAssetAPIUrls keeps the locked API endpoints of GitHub release files, the lockfile is not trusted
+// with the token: any other host would receive it along with the download
+func lockedAssetAPIUrls(tool manifest.LockedTool) map[string]string {
+	if tool.Resolver != resolver.GithubResolverName {
+		return nil
+	}
+	apiUrls := make(map[string]string, len(tool.AssetAPIURLs))
+	for downloadUrl, apiUrl := range tool.AssetAPIURLs {
+		if !resolver.IsGitHubAssetAPIUrl(downloadUrl, apiUrl) {
+			logging.Logger().Debug("ignoring locked asset API url", "binary", tool.Name, "url", downloadUrl,
+				"apiUrl", apiUrl)
+			continue
+		}
+		apiUrls[downloadUrl] = apiUrl
+	}
+	return apiUrls
+}
+
+// withSource forces the resolver used for the binaries
+func withSource(binariesInfo []dto.BinaryInfo, source string) ([]dto.BinaryInfo, error) {
+	if source == "" {
+		return binariesInfo, nil
+	}
+	if _, ok := resolver.GetRegistryResolver().Lookup(source); !ok {
+		return nil, fmt.Errorf("unknown source %s", source)
+	}
+	for i := range binariesInfo {
+		if binariesInfo[i].Resolver != "" && binariesInfo[i].Resolver != source {
+			return nil, fmt.Errorf("source %s cannot be used with --url", source)
+		}
+		binariesInfo[i].Resolver = source
+	}
+	return binariesInfo, nil
+}
+
+// withUrlTemplate makes the binaries resolved from an arbitrary url instead of a forge
+func withUrlTemplate(binariesInfo []dto.BinaryInfo, urlTemplate, latestVersionUrl string,
+	templateVars map[string]string,
+) []dto.BinaryInfo {
+	if urlTemplate == "" {
+		return binariesInfo
+	}
+	for i := range binariesInfo {
+		binariesInfo[i].Resolver = resolver.URLResolverName
+		binariesInfo[i].URLTemplate = urlTemplate
+		binariesInfo[i].LatestVersionURL = latestVersionUrl
+		binariesInfo[i].TemplateVars = templateVars
+	}
+	return binariesInfo
 }
 
 func newInstallCommand(localInstaller installer.Installer, localState state.State) *cobra.Command {
-	var version string
+	var version, source, urlTemplate, latestVersionUrl, lockPath string
+	var templateVars map[string]string
+	var frozen bool
 	cfg := InstallCommandConfig{
 		azaInstaller: localInstaller,
 		azaState:     localState,
@@ -111,27 +288,52 @@ func newInstallCommand(localInstaller installer.Installer, localState state.Stat
 		Use:   InstallUseMessage,
 		Short: InstallShortMessage,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if frozen {
+				if cmd.Flags().Changed("version") || source != "" || urlTemplate != "" {
+					return errors.New(FrozenFlagsErrorMessage)
+				}
+				return executeFrozenInstallCommand(cmd.Context(), cfg, lockPath, args...)
+			}
 			if len(args) == 0 {
 				_ = cmd.Help()
 				return errors.New(ArgsCountErrorMessage)
 			}
 
-			binaryInfoSlice := binariesInfoFromArgs(args, version)
-			for _, binaryInfo := range binaryInfoSlice {
-				err := installBinary(&binaryInfo, cfg)
-				if err != nil {
+			if urlTemplate == "" && latestVersionUrl != "" {
+				return errors.New(LatestUrlWithoutUrlErrorMessage)
+			}
+			if semver.IsConstraint(version) {
+				if _, err := semver.ParseConstraint(version); err != nil {
 					return err
 				}
 			}
 
-			return nil
+			binaryInfoSlice, err := withSource(withUrlTemplate(binariesInfoFromArgs(args, version),
+				urlTemplate, latestVersionUrl, templateVars), source)
+			if err != nil {
+				return err
+			}
+			return executeInstallCommand(cmd.Context(), cfg, binaryInfoSlice)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
-	cmd.Flags().StringVarP(&version, "version", "v",
-		DefaultBinaryVersion, "desired version of the binary")
+	cmd.Flags().StringVarP(&version, "version", "v", DefaultBinaryVersion,
+		"desired version of the binary, exact tag or constraint (e.g. '~1.2', '>=1.30,<1.32', '1.x')")
+	cmd.Flags().StringVar(&source, "source", "",
+		"only resolve the binary from this source (github, gitlab, url)")
+	cmd.Flags().StringVar(&source, "resolver", "", "alias of --source")
+	_ = cmd.Flags().MarkHidden("resolver")
+	cmd.Flags().StringVar(&urlTemplate, "url", "",
+		"install from an url template, e.g. 'https://dl.example.com/{{.Version}}/{{.OS}}/{{.Arch}}/tool'")
+	cmd.Flags().StringVar(&latestVersionUrl, "latest-url", "",
+		"url returning the latest version as plain text (e.g. stable.txt), used with --url")
+	cmd.Flags().StringToStringVar(&templateVars, "var", nil,
+		"extra variables for the url template, available as {{.Vars.<key>}}")
+	cmd.Flags().BoolVar(&frozen, "frozen", false,
+		"install the exact versions of the lockfile (all of them without argument), failing on digest mismatch")
+	cmd.Flags().StringVar(&lockPath, "lock-file", manifest.LockFileName, "lockfile used with --frozen")
 
 	return cmd
 }
@@ -1,15 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
 	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/manifest"
 	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+	"gitlab.com/ludovic-alarcon/azabox/internal/semver"
 )
 
 func TestNewInstallCommand(t *testing.T) {
@@ -20,6 +29,7 @@ func TestNewInstallCommand(t *testing.T) {
 		require.NotNil(t, localInstaller)
 
 		cmd := newInstallCommand(localInstaller, dummyState)
+		cmd.SetContext(t.Context())
 
 		require.NotNil(t, cmd)
 		assert.Equal(t, InstallUseMessage, cmd.Use)
@@ -37,25 +47,54 @@ func TestInstallCommand(t *testing.T) {
 		require.NotNil(t, localInstaller)
 
 		cmd := newInstallCommand(localInstaller, dummyState)
+		cmd.SetContext(t.Context())
 		err = cmd.RunE(cmd, []string{})
 		require.Error(t, err)
 		assert.Equal(t, ArgsCountErrorMessage, err.Error())
 	})
 
-	t.Run("should not return an error when there is at least one args provided", func(t *testing.T) {
+	t.Run("should attempt every binary and report the ones not found", func(t *testing.T) {
 		logging.UseInMemoryLogger()
-		dummyState := &DummyState{}
+		dummyState := &DummyState{binaries: make(map[string]dto.BinaryInfo)}
 		localInstaller, err := installer.New()
 		require.NoError(t, err)
 		require.NotNil(t, localInstaller)
 
 		cmd := newInstallCommand(localInstaller, dummyState)
+		cmd.SetContext(t.Context())
+		require.NoError(t, cmd.Flags().Set("source", resolver.URLResolverName))
 
 		err = cmd.RunE(cmd, []string{"foo"})
-		assert.NoError(t, err)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, resolver.ErrNotResolved)
 
 		err = cmd.RunE(cmd, []string{"foo", "bar"})
-		assert.NoError(t, err)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 of 2 installs failed")
+		assert.Contains(t, err.Error(), `binary not found for "bar/bar"`)
+		var exitErr *ExitError
+		assert.NotErrorAs(t, err, &exitErr, "nothing was installed, it is not a partial failure")
+		assert.Equal(t, 2, dummyState.saveCount)
+	})
+
+	t.Run("should return an error when latest url is used without url", func(t *testing.T) {
+		cmd := newInstallCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+		require.NoError(t, cmd.Flags().Set("latest-url", "https://foo.bar/stable.txt"))
+
+		err := cmd.RunE(cmd, []string{"foo"})
+		require.Error(t, err)
+		assert.Equal(t, LatestUrlWithoutUrlErrorMessage, err.Error())
+	})
+
+	t.Run("should return an error on invalid version constraint", func(t *testing.T) {
+		cmd := newInstallCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+		require.NoError(t, cmd.Flags().Set("version", ">=foo"))
+
+		err := cmd.RunE(cmd, []string{"foo"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, semver.ErrInvalidConstraint)
 	})
 
 	t.Run("should return an error when binary already present in state", func(t *testing.T) {
@@ -76,13 +115,14 @@ func TestInstallCommand(t *testing.T) {
 		require.NoError(t, err)
 
 		cmd := newInstallCommand(localInstaller, dummyState)
+		cmd.SetContext(t.Context())
 		err = cmd.RunE(cmd, []string{name})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "binary already installed")
 	})
 }
 
-func TestInstallBinary(t *testing.T) {
+func TestExecuteInstallCommand(t *testing.T) {
 	t.Run("should handle error on state failed", func(t *testing.T) {
 		dummyState := &DummyState{onError: true}
 		localInstaller, err := installer.New()
@@ -92,20 +132,60 @@ func TestInstallBinary(t *testing.T) {
 			azaInstaller: localInstaller,
 			azaState:     dummyState,
 		}
-		binaryInfo := dto.BinaryInfo{
-			FullName: TestBinaryFullName,
-			Name:     TestBinaryName,
-			Owner:    TestBinaryName,
-			Version:  TestBinaryVersion,
-		}
 
-		err = installBinary(&binaryInfo, cfg)
+		err = executeInstallCommand(t.Context(), cfg, binariesInfoFromArgs([]string{TestBinaryName}, TestBinaryVersion))
 
 		require.Error(t, err)
 		assert.Equal(t, DummyStateErrorMessage, err.Error())
 		assert.Equal(t, 0, dummyState.saveCount)
 	})
 
+	t.Run("should save installed binaries when others failed", func(t *testing.T) {
+		dummyState := &DummyState{binaries: make(map[string]dto.BinaryInfo)}
+		dummyInstaller := &DummyInstaller{}
+		dummyResolver := &DummyResolver{}
+		cfg := InstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		binariesInfo := binariesInfoFromArgs([]string{"foo", "bar", "baz"}, TestBinaryVersion)
+		binariesInfo[1].Resolver = "other"
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		err := executeInstallCommand(t.Context(), cfg, binariesInfo)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, PartialFailureExitCode, exitErr.Code)
+		assert.Equal(t, "1 of 3 installs failed:\n  - bar: unknown resolver other", err.Error())
+		assert.Equal(t, 2, dummyInstaller.installCount, "every binary should be attempted")
+		assert.Equal(t, 1, dummyState.saveCount)
+		assert.True(t, dummyState.Has("foo/foo"))
+		assert.False(t, dummyState.Has("bar/bar"))
+		assert.True(t, dummyState.Has("baz/baz"))
+	})
+
+	t.Run("should stop installing and save once cancelled", func(t *testing.T) {
+		dummyState := &DummyState{binaries: make(map[string]dto.BinaryInfo)}
+		dummyInstaller := &DummyInstaller{}
+		cfg := InstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		err := executeInstallCommand(ctx, cfg, binariesInfoFromArgs([]string{"foo", "bar"}, TestBinaryVersion))
+
+		require.Error(t, err)
+		assert.Equal(t, "2 of 2 installs failed:\n  - foo: context canceled\n  - bar: context canceled", err.Error())
+		assert.Equal(t, 0, dummyInstaller.installCount)
+		assert.Equal(t, 1, dummyState.saveCount)
+	})
+}
+
+func TestInstallBinary(t *testing.T) {
 	t.Run("should resolve url and install binary", func(t *testing.T) {
 		dummyState := &DummyState{
 			binaries: make(map[string]dto.BinaryInfo, 1),
@@ -124,7 +204,7 @@ func TestInstallBinary(t *testing.T) {
 		}
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
-		err := installBinary(&binaryInfo, cfg)
+		err := installBinary(t.Context(), &binaryInfo, cfg)
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		assert.NoError(t, err)
@@ -132,11 +212,38 @@ func TestInstallBinary(t *testing.T) {
 			"should have called resolve method once")
 		assert.Equal(t, 1, dummyInstaller.installCount,
 			"should have called install method once")
-		assert.Equal(t, 1, dummyState.saveCount,
-			"should have called save method once")
 		assert.True(t, dummyState.Has(binaryInfo.FullName), "binary should be present in state")
 	})
 
+	t.Run("should only use the resolver set on the binary", func(t *testing.T) {
+		dummyState := &DummyState{
+			binaries: make(map[string]dto.BinaryInfo, 1),
+		}
+		dummyInstaller := &DummyInstaller{}
+		dummyResolver := &DummyResolver{}
+		cfg := InstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		binaryInfo := dto.BinaryInfo{
+			FullName: TestBinaryFullName,
+			Name:     TestBinaryName,
+			Owner:    TestBinaryName,
+			Version:  TestBinaryVersion,
+			Resolver: "other",
+		}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+
+		err := installBinary(t.Context(), &binaryInfo, cfg)
+		resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown resolver other")
+		assert.Equal(t, 0, dummyResolver.resolveCount, "should not have called other resolvers")
+		assert.Equal(t, 0, dummyInstaller.installCount)
+		assert.False(t, dummyState.Has(binaryInfo.FullName), "binary should not be present in state")
+	})
+
 	t.Run("should handle error", func(t *testing.T) {
 		dummyState := &DummyState{}
 		dummyInstaller := &DummyInstaller{onError: true}
@@ -153,7 +260,7 @@ func TestInstallBinary(t *testing.T) {
 		}
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
-		err := installBinary(&binaryInfo, cfg)
+		err := installBinary(t.Context(), &binaryInfo, cfg)
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		require.Error(t, err)
@@ -199,13 +306,13 @@ func TestCreateBinaryInfo(t *testing.T) {
 				}{owner: "foo", name: "bar"},
 			},
 			{
-				name:    "wrong format",
+				name:    "nested group format",
 				binary:  "foo/bar/toto",
 				version: "0.1.2",
 				expected: struct {
 					owner string
 					name  string
-				}{owner: "foo", name: "bar"},
+				}{owner: "foo/bar", name: "toto"},
 			},
 		}
 
@@ -217,9 +324,17 @@ func TestCreateBinaryInfo(t *testing.T) {
 				assert.Equal(t, fmt.Sprintf("%s/%s",
 					tc.expected.owner, tc.expected.name), binaryInfo.FullName)
 				assert.Equal(t, tc.version, binaryInfo.Version)
+				assert.Empty(t, binaryInfo.Constraint)
 			})
 		}
 	})
+
+	t.Run("should store version constraint", func(t *testing.T) {
+		binaryInfo := createBinaryInfo("foo", ">=1.30,<1.32")
+
+		assert.Equal(t, ">=1.30,<1.32", binaryInfo.Version)
+		assert.Equal(t, ">=1.30,<1.32", binaryInfo.Constraint)
+	})
 }
 
 func TestBinariesInfoFromArgs(t *testing.T) {
@@ -302,3 +417,270 @@ func TestBinariesInfoFromArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestWithUrlTemplate(t *testing.T) {
+	t.Run("should keep binaries untouched without template", func(t *testing.T) {
+		binariesInfo := withUrlTemplate(binariesInfoFromArgs([]string{"foo"}, "latest"), "", "", nil)
+
+		require.Len(t, binariesInfo, 1)
+		assert.Empty(t, binariesInfo[0].Resolver)
+		assert.Empty(t, binariesInfo[0].URLTemplate)
+	})
+
+	t.Run("should force url resolver and save template", func(t *testing.T) {
+		urlTemplate := "https://foo.bar/{{.Version}}/{{.Name}}"
+		latestUrl := "https://foo.bar/stable.txt"
+		vars := map[string]string{"foo": "bar"}
+
+		binariesInfo := withUrlTemplate(binariesInfoFromArgs([]string{"foo", "bar"}, "v1.0.0"),
+			urlTemplate, latestUrl, vars)
+
+		require.Len(t, binariesInfo, 2)
+		for _, binaryInfo := range binariesInfo {
+			assert.Equal(t, resolver.URLResolverName, binaryInfo.Resolver)
+			assert.Equal(t, urlTemplate, binaryInfo.URLTemplate)
+			assert.Equal(t, latestUrl, binaryInfo.LatestVersionURL)
+			assert.Equal(t, vars, binaryInfo.TemplateVars)
+		}
+	})
+}
+
+func TestWithSource(t *testing.T) {
+	dummyResolver := &DummyResolver{}
+	resolver.GetRegistryResolver().Register(dummyResolver)
+	defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+	t.Run("should keep binaries untouched without source", func(t *testing.T) {
+		binariesInfo, err := withSource(binariesInfoFromArgs([]string{"foo"}, "latest"), "")
+
+		require.NoError(t, err)
+		assert.Empty(t, binariesInfo[0].Resolver)
+	})
+
+	t.Run("should force resolver", func(t *testing.T) {
+		binariesInfo, err := withSource(binariesInfoFromArgs([]string{"foo", "bar"}, "latest"), DummyResolverName)
+
+		require.NoError(t, err)
+		for _, binaryInfo := range binariesInfo {
+			assert.Equal(t, DummyResolverName, binaryInfo.Resolver)
+		}
+	})
+
+	t.Run("should handle unknown source", func(t *testing.T) {
+		_, err := withSource(binariesInfoFromArgs([]string{"foo"}, "latest"), "unknown")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown source")
+	})
+
+	t.Run("should handle source conflicting with url", func(t *testing.T) {
+		binariesInfo := withUrlTemplate(binariesInfoFromArgs([]string{"foo"}, "latest"),
+			"https://foo.bar/{{.Version}}", "", nil)
+
+		_, err := withSource(binariesInfo, DummyResolverName)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be used with --url")
+	})
+}
+
+func writeTestLock(t *testing.T, tools ...manifest.LockedTool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), manifest.LockFileName)
+	require.NoError(t, manifest.Lock{Tools: tools}.Save(path))
+	return path
+}
+
+func TestExecuteFrozenInstallCommand(t *testing.T) {
+	lockedFoo := manifest.LockedTool{
+		Name:     TestBinaryFullName,
+		Version:  TestBinaryVersion,
+		Resolver: DummyResolverName,
+		URL:      TestResolvedURL,
+		SHA256:   strings.Repeat("a", 64),
+	}
+	lockedBar := manifest.LockedTool{Name: "bar/bar", Version: "v1.0.0", Resolver: DummyResolverName,
+		URL: "https://fake.url/bar.tar.gz"}
+
+	t.Run("should install every locked binary without resolving them", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		dummyState := createFakeState(nil)
+		dummyInstaller := &DummyInstaller{}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo, lockedBar))
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, dummyInstaller.installCount)
+		assert.Equal(t, 0, dummyResolver.resolveCount+dummyResolver.resolveLatestVersionCount,
+			"locked binaries should not be resolved")
+		info, ok := dummyState.Entry(TestBinaryFullName)
+		require.True(t, ok)
+		assert.Equal(t, DummyResolverName, info.Resolver)
+		assert.Equal(t, lockedFoo.SHA256, info.LockedSHA256)
+		versionInfo, ok := info.FindVersion(TestBinaryVersion)
+		require.True(t, ok)
+		assert.Equal(t, TestResolvedURL, versionInfo.URL)
+		assert.Equal(t, 1, dummyState.saveCount)
+	})
+
+	t.Run("should only install the requested binaries", func(t *testing.T) {
+		dummyState := createFakeState(nil)
+		dummyInstaller := &DummyInstaller{}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo, lockedBar), "bar")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		assert.True(t, dummyState.Has("bar/bar"))
+	})
+
+	t.Run("should skip binaries already on their locked version", func(t *testing.T) {
+		binaryInfo := newTestBinary(TestBinaryVersion)
+		binaryInfo.Versions[0].SHA256 = lockedFoo.SHA256
+		dummyState := createFakeState([]dto.BinaryInfo{binaryInfo})
+		dummyInstaller := &DummyInstaller{installedVersions: []string{TestBinaryVersion}}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo))
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, dummyInstaller.installCount)
+	})
+
+	t.Run("should reinstall locked version with another digest", func(t *testing.T) {
+		binaryInfo := newTestBinary(TestBinaryVersion)
+		binaryInfo.Versions[0].SHA256 = strings.Repeat("b", 64)
+		dummyState := createFakeState([]dto.BinaryInfo{binaryInfo})
+		dummyInstaller := &DummyInstaller{installedVersions: []string{TestBinaryVersion}}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo))
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, lockedFoo.SHA256, info.LockedSHA256, "download should be checked against the lock")
+	})
+
+	t.Run("should install with the locked release files", func(t *testing.T) {
+		locked := lockedFoo
+		locked.Resolver = resolver.GithubResolverName
+		locked.URL = "https://github.com/foo/foo/releases/download/v1.0.0/foo.tar.gz"
+		locked.ChecksumURL = "https://github.com/foo/foo/releases/download/v1.0.0/checksums.txt"
+		locked.SignatureURLs = []string{locked.ChecksumURL + ".sig"}
+		locked.AssetAPIURLs = map[string]string{locked.URL: "https://api.github.com/repos/foo/foo/releases/assets/1"}
+		dummyInstaller := &DummyInstaller{}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: createFakeState(nil)}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, locked))
+
+		require.NoError(t, err)
+		assert.Equal(t, locked.ChecksumURL, dummyInstaller.lastInstalled.ChecksumURL)
+		assert.Equal(t, locked.SignatureURLs, dummyInstaller.lastInstalled.SignatureURLs)
+		assert.Equal(t, locked.AssetAPIURLs, dummyInstaller.lastInstalled.AssetAPIURLs)
+	})
+
+	t.Run("should track how a binary new to the state is resolved", func(t *testing.T) {
+		locked := lockedFoo
+		locked.Constraint = "~1.0"
+		locked.URLTemplate = "https://fake.url/{{.Version}}/{{.Name}}"
+		locked.TemplateVars = map[string]string{"Flavor": "static"}
+		locked.LatestVersionURL = "https://fake.url/stable.txt"
+		dummyState := createFakeState(nil)
+		cfg := InstallCommandConfig{azaInstaller: &DummyInstaller{}, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, locked))
+
+		require.NoError(t, err)
+		info, ok := dummyState.Entry(TestBinaryFullName)
+		require.True(t, ok)
+		assert.Equal(t, "~1.0", info.Constraint)
+		assert.Equal(t, "~1.0", info.Version, "the constraint should be kept as requested version")
+		assert.Equal(t, locked.URLTemplate, info.URLTemplate)
+		assert.Equal(t, locked.TemplateVars, info.TemplateVars)
+		assert.Equal(t, locked.LatestVersionURL, info.LatestVersionURL)
+	})
+
+	t.Run("should keep how a binary is resolved with a lock not recording it", func(t *testing.T) {
+		binaryInfo := newTestBinary(FakeVersionToUpdate)
+		binaryInfo.Constraint = "~1.0"
+		binaryInfo.URLTemplate = "https://fake.url/{{.Version}}/{{.Name}}"
+		dummyState := createFakeState([]dto.BinaryInfo{binaryInfo})
+		cfg := InstallCommandConfig{azaInstaller: &DummyInstaller{}, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo))
+
+		require.NoError(t, err)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, "~1.0", info.Constraint)
+		assert.Equal(t, binaryInfo.URLTemplate, info.URLTemplate)
+	})
+
+	t.Run("should not send the token to a foreign host", func(t *testing.T) {
+		httpclient.SetGitHubToken("secret")
+		defer httpclient.SetGitHubToken("")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"), "the token should never leave GitHub")
+			assert.Equal(t, "/download/foo.tar.gz", r.URL.Path)
+			_, _ = io.WriteString(w, "binary")
+		}))
+		defer server.Close()
+		locked := lockedFoo
+		locked.Resolver = resolver.GithubResolverName
+		locked.URL = server.URL + "/download/foo.tar.gz"
+		locked.AssetAPIURLs = map[string]string{
+			"https://github.com/foo/foo/releases/download/v1.0.0/foo.tar.gz": server.URL + "/repos/foo/foo/assets/1",
+			locked.URL: server.URL + "/repos/foo/foo/assets/2",
+		}
+		dummyInstaller := &DummyInstaller{}
+		cfg := InstallCommandConfig{azaInstaller: dummyInstaller, azaState: createFakeState(nil)}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, locked))
+
+		require.NoError(t, err)
+		assert.Empty(t, dummyInstaller.lastInstalled.AssetAPIURLs)
+		resp, err := httpclient.Client.Get(locked.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "binary", string(data))
+	})
+
+	t.Run("should handle binary not locked", func(t *testing.T) {
+		dummyState := createFakeState(nil)
+		cfg := InstallCommandConfig{azaInstaller: &DummyInstaller{}, azaState: dummyState}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, writeTestLock(t, lockedFoo), "unknown")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "binary unknown is not in")
+		assert.Equal(t, 0, dummyState.loadCount)
+	})
+
+	t.Run("should handle missing lockfile", func(t *testing.T) {
+		cfg := InstallCommandConfig{azaInstaller: &DummyInstaller{}, azaState: createFakeState(nil)}
+
+		err := executeFrozenInstallCommand(t.Context(), cfg, filepath.Join(t.TempDir(), manifest.LockFileName))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "run the sync command to create it")
+	})
+
+	t.Run("should refuse resolution flags", func(t *testing.T) {
+		cmd := newInstallCommand(&DummyInstaller{}, createFakeState(nil))
+		cmd.SetContext(t.Context())
+		require.NoError(t, cmd.Flags().Set("frozen", "true"))
+		require.NoError(t, cmd.Flags().Set("version", "v1.0.0"))
+
+		err := cmd.RunE(cmd, []string{})
+
+		require.Error(t, err)
+		assert.Equal(t, FrozenFlagsErrorMessage, err.Error())
+	})
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -36,14 +38,24 @@ func executeListCommand(azaState state.State) (string, error) {
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return "", err
 	}
+	defer azaState.Unlock()
 
 	var sb strings.Builder
 	if len(azaState.Entries()) == 0 {
 		sb.WriteString("No binary installed\n")
 	} else {
 		sb.WriteString("Binaries installed:\n")
-		for _, binary := range azaState.Entries() {
+		entries := azaState.Entries()
+		for _, name := range slices.Sorted(maps.Keys(entries)) {
+			binary := entries[name]
 			sb.WriteString(fmt.Sprintf("- %s\n", binary.String()))
+			for _, versionInfo := range binary.Versions {
+				marker := " "
+				if versionInfo.Version == binary.ActiveVersion {
+					marker = "*"
+				}
+				sb.WriteString(fmt.Sprintf("  %s %s\n", marker, versionInfo.String()))
+			}
 		}
 	}
 	return sb.String(), nil
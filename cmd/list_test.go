@@ -8,17 +8,6 @@ import (
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
 )
 
-func createFakeState(binaries []dto.BinaryInfo) *DummyState {
-	dummyState := &DummyState{
-		binaries: make(map[string]dto.BinaryInfo, len(binaries)),
-	}
-
-	for _, binaryInfo := range binaries {
-		dummyState.UpdateEntrie(binaryInfo)
-	}
-	return dummyState
-}
-
 func TestNewListCommand(t *testing.T) {
 	t.Run("should create a new list command", func(t *testing.T) {
 		dummyState := &DummyState{}
@@ -58,6 +47,24 @@ func TestExecuteListCommand(t *testing.T) {
 					"foo/bar in version 0.0.2",
 				},
 			},
+			{
+				name: "multiple versions",
+				binaries: []dto.BinaryInfo{
+					{
+						FullName: "foo/foo", Name: "foo", Owner: "foo",
+						InstalledVersion: "0.0.2", ActiveVersion: "0.0.1",
+						Versions: []dto.VersionInfo{
+							{Version: "0.0.1", Path: "/bin/foo-0.0.1"},
+							{Version: "0.0.2", Path: "/bin/foo-0.0.2"},
+						},
+					},
+				},
+				expected: []string{
+					"foo in version 0.0.1 (latest installed 0.0.2)",
+					"* 0.0.1 (/bin/foo-0.0.1)",
+					"  0.0.2 (/bin/foo-0.0.2)",
+				},
+			},
 			{
 				name:     "empty state",
 				binaries: []dto.BinaryInfo{},
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"io"
+	"slices"
+	"sync"
 
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
 )
@@ -18,6 +22,40 @@ const (
 	DummyInstallerErrorMessage = "some error in installer"
 )
 
+func createFakeState(binaries []dto.BinaryInfo) *DummyState {
+	dummyState := &DummyState{
+		binaries: make(map[string]dto.BinaryInfo, len(binaries)),
+	}
+
+	for _, binaryInfo := range binaries {
+		dummyState.UpdateEntrie(binaryInfo)
+	}
+	return dummyState
+}
+
+// newTestBinary returns the test binary installed and active in the given version
+func newTestBinary(version string) dto.BinaryInfo {
+	return dto.BinaryInfo{
+		FullName:         TestBinaryFullName,
+		Name:             TestBinaryName,
+		Owner:            TestBinaryName,
+		Version:          version,
+		InstalledVersion: version,
+		ActiveVersion:    version,
+		Resolver:         DummyResolverName,
+		Versions:         []dto.VersionInfo{{Version: version}},
+	}
+}
+
+// newNamedTestBinary is newTestBinary for a binary named <name>/<name>
+func newNamedTestBinary(name, version string) dto.BinaryInfo {
+	binaryInfo := newTestBinary(version)
+	binaryInfo.FullName = name + "/" + name
+	binaryInfo.Name = name
+	binaryInfo.Owner = name
+	return binaryInfo
+}
+
 type DummyState struct {
 	loadCount int
 	saveCount int
@@ -34,6 +72,14 @@ func (s *DummyState) Load() error {
 	return nil
 }
 
+func (s *DummyState) Peek() error {
+	s.loadCount++
+	if s.onError {
+		return errors.New(DummyStateErrorMessage)
+	}
+	return nil
+}
+
 func (s *DummyState) Save() error {
 	s.saveCount++
 	if s.onError {
@@ -42,10 +88,18 @@ func (s *DummyState) Save() error {
 	return nil
 }
 
+func (s *DummyState) Unlock() error {
+	return nil
+}
+
 func (s *DummyState) UpdateEntrie(binaryInfo dto.BinaryInfo) {
 	s.binaries[binaryInfo.FullName] = binaryInfo
 }
 
+func (s *DummyState) RemoveEntry(binaryName string) {
+	delete(s.binaries, binaryName)
+}
+
 func (s *DummyState) Has(binaryName string) bool {
 	_, ok := s.binaries[binaryName]
 	return ok
@@ -60,21 +114,39 @@ func (s *DummyState) Entries() map[string]dto.BinaryInfo {
 	return s.binaries
 }
 
+// DummyResolver and DummyInstaller are safe for concurrent use, update runs several jobs at once
 type DummyResolver struct {
+	mu sync.Mutex
+
 	resolveCount              int
 	resolveLatestVersionCount int
 	onError                   bool
+
+	versions         []string
+	resolvedVersions []string
 }
 
-func (r *DummyResolver) Resolve(*dto.BinaryInfo) (string, error) {
+func (r *DummyResolver) Resolve(_ context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.resolveCount++
 	if r.onError {
 		return "", errors.New(DummyResolverErrorMessage)
 	}
+	r.resolvedVersions = append(r.resolvedVersions, binaryInfo.Version)
 	return TestResolvedURL, nil
 }
 
-func (r *DummyResolver) ResolveLatestVersion(dto.BinaryInfo) (string, error) {
+func (r *DummyResolver) ListVersions(context.Context, dto.BinaryInfo) ([]string, error) {
+	if r.onError {
+		return nil, errors.New(DummyResolverErrorMessage)
+	}
+	return r.versions, nil
+}
+
+func (r *DummyResolver) ResolveLatestVersion(context.Context, dto.BinaryInfo) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.resolveLatestVersionCount++
 	if r.onError {
 		return "", errors.New(DummyResolverErrorMessage)
@@ -87,15 +159,73 @@ func (r *DummyResolver) Name() string {
 }
 
 type DummyInstaller struct {
-	installCount int
-	onError      bool
+	mu sync.Mutex
+
+	installCount   int
+	uninstallCount int
+	linkCount      int
+	unlinkCount    int
+	onError        bool
+
+	installedVersions []string
+	// lastInstalled is the binary as given to the last install
+	lastInstalled dto.BinaryInfo
 }
 
-func (i *DummyInstaller) Install(binaryInfo *dto.BinaryInfo, url string) error {
+func (i *DummyInstaller) Install(_ context.Context, _ io.Writer, binaryInfo *dto.BinaryInfo, url string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.installCount++
+	i.lastInstalled = *binaryInfo
 	if i.onError {
 		return errors.New(DummyInstallerErrorMessage)
 	}
 	binaryInfo.InstalledVersion = TestBinaryVersion
+	binaryInfo.ActiveVersion = TestBinaryVersion
+	binaryInfo.AddVersion(dto.VersionInfo{Version: TestBinaryVersion, URL: url})
+	return nil
+}
+
+func (i *DummyInstaller) InstallVersion(_ context.Context, _ io.Writer, binaryInfo *dto.BinaryInfo, url string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.installCount++
+	if i.onError {
+		return errors.New(DummyInstallerErrorMessage)
+	}
+	binaryInfo.InstalledVersion = TestBinaryVersion
+	binaryInfo.AddVersion(dto.VersionInfo{Version: TestBinaryVersion, URL: url})
+	return nil
+}
+
+func (i *DummyInstaller) Uninstall(*dto.BinaryInfo, string) error {
+	i.uninstallCount++
+	if i.onError {
+		return errors.New(DummyInstallerErrorMessage)
+	}
+	return nil
+}
+
+func (i *DummyInstaller) IsInstalled(_ *dto.BinaryInfo, version string) bool {
+	return slices.Contains(i.installedVersions, version)
+}
+
+func (i *DummyInstaller) BinaryPath(binaryInfo *dto.BinaryInfo, version string) string {
+	return "/fake/bin/" + binaryInfo.Name + "-" + version
+}
+
+func (i *DummyInstaller) Link(*dto.BinaryInfo, string) error {
+	i.linkCount++
+	if i.onError {
+		return errors.New(DummyInstallerErrorMessage)
+	}
+	return nil
+}
+
+func (i *DummyInstaller) Unlink(*dto.BinaryInfo) error {
+	i.unlinkCount++
+	if i.onError {
+		return errors.New(DummyInstallerErrorMessage)
+	}
 	return nil
 }
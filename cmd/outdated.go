@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	OutdatedUseMessage   = "outdated [binary...]"
+	OutdatedShortMessage = "report installed binaries with a newer version available, without installing anything"
+
+	OutdatedStatusUpToDate = "up to date"
+	OutdatedStatusOutdated = "outdated"
+	OutdatedStatusPinned   = "pinned"
+	OutdatedStatusError    = "error"
+)
+
+type OutdatedCommandConfig struct {
+	azaState   state.State
+	jsonOutput bool
+}
+
+type OutdatedEntry struct {
+	Name       string `json:"name"`
+	Current    string `json:"current"`
+	Latest     string `json:"latest"`
+	Constraint string `json:"constraint,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newOutdatedCommand(azaState state.State) *cobra.Command {
+	cfg := OutdatedCommandConfig{
+		azaState: azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   OutdatedUseMessage,
+		Short: OutdatedShortMessage,
+		Long: OutdatedShortMessage + `.
+It exits with code 2 when a binary is outdated and 1 when a latest version could not be checked,
+pinned binaries are reported but never counted as outdated.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := executeOutdatedCommand(cmd.Context(), cfg, args...)
+			if err != nil {
+				return err
+			}
+			report, err := formatOutdated(entries, cfg.jsonOutput)
+			if err != nil {
+				return err
+			}
+			fmt.Print(report)
+			return outdatedResult(entries)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().BoolVar(&cfg.jsonOutput, "json", false, "print the report as JSON")
+
+	return cmd
+}
+
+func executeOutdatedCommand(ctx context.Context, cfg OutdatedCommandConfig, args ...string) (
+	[]OutdatedEntry, error,
+) {
+	// the report only reads the state, it must not hold the lock during the lookups
+	err := cfg.azaState.Peek()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	entries := cfg.azaState.Entries()
+	names := slices.Sorted(maps.Keys(entries))
+	if len(args) > 0 {
+		names = names[:0]
+		for _, binaryName := range args {
+			name := dto.NormalizeName(binaryName)
+			if _, ok := entries[name]; !ok {
+				return nil, fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName)
+			}
+			names = append(names, name)
+		}
+	}
+
+	report := make([]OutdatedEntry, 0, len(names))
+	for _, name := range names {
+		binaryInfo := entries[name]
+		entry := OutdatedEntry{
+			Name:       binaryInfo.DisplayName(),
+			Current:    binaryInfo.InstalledVersion,
+			Constraint: binaryInfo.Constraint,
+		}
+
+		latest, _, err := resolveLatestVersion(ctx, binaryInfo)
+		switch {
+		case err != nil:
+			logging.Logger().Debug("outdated check failed", "name", binaryInfo.DisplayName(), "error", err)
+			entry.Status = OutdatedStatusError
+			entry.Error = err.Error()
+		case binaryInfo.Pinned:
+			entry.Latest = latest
+			entry.Status = OutdatedStatusPinned
+		case !sameVersion(latest, binaryInfo.InstalledVersion):
+			entry.Latest = latest
+			entry.Status = OutdatedStatusOutdated
+		default:
+			entry.Latest = latest
+			entry.Status = OutdatedStatusUpToDate
+		}
+		report = append(report, entry)
+	}
+	return report, nil
+}
+
+func formatOutdated(entries []OutdatedEntry, jsonOutput bool) (string, error) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+
+	if len(entries) == 0 {
+		return "No binary installed\n", nil
+	}
+
+	var sb strings.Builder
+	writer := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tCURRENT\tLATEST\tSTATUS")
+	for _, entry := range entries {
+		latest, status := entry.Latest, entry.Status
+		if latest == "" {
+			latest = "-"
+		}
+		if entry.Constraint != "" {
+			latest = fmt.Sprintf("%s (%s)", latest, entry.Constraint)
+		}
+		if entry.Error != "" {
+			status = fmt.Sprintf("%s: %s", status, entry.Error)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", entry.Name, entry.Current, latest, status)
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// outdatedResult turns the report into the command exit code
+func outdatedResult(entries []OutdatedEntry) error {
+	failed, outdated := 0, 0
+	for _, entry := range entries {
+		switch entry.Status {
+		case OutdatedStatusError:
+			failed++
+		case OutdatedStatusOutdated:
+			outdated++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not check the latest version of %d binaries", failed)
+	}
+	if outdated > 0 {
+		return &ExitError{Code: OutdatedExitCode}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+func TestNewOutdatedCommand(t *testing.T) {
+	t.Run("should create a new outdated command", func(t *testing.T) {
+		dummyState := &DummyState{}
+		cmd := newOutdatedCommand(dummyState)
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, OutdatedUseMessage, cmd.Use)
+		assert.Equal(t, OutdatedShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.NotNil(t, cmd.Flags().Lookup("json"))
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+}
+
+func TestExecuteOutdatedCommand(t *testing.T) {
+	binaries := []dto.BinaryInfo{
+		{FullName: "foo/foo", Name: "foo", Owner: "foo", InstalledVersion: FakeVersionToUpdate,
+			Resolver: DummyResolverName},
+		{FullName: "foo/bar", Name: "bar", Owner: "foo", InstalledVersion: TestBinaryVersion,
+			Resolver: DummyResolverName},
+		{FullName: "foo/baz", Name: "baz", Owner: "foo", InstalledVersion: FakeVersionToUpdate,
+			Resolver: DummyResolverName, Pinned: true},
+	}
+
+	t.Run("should report outdated binaries without installing", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState(binaries)
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		entries, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: dummyState})
+
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, OutdatedEntry{Name: "foo/bar", Current: TestBinaryVersion, Latest: TestBinaryVersion,
+			Status: OutdatedStatusUpToDate}, entries[0])
+		assert.Equal(t, OutdatedStatusPinned, entries[1].Status)
+		assert.Equal(t, OutdatedEntry{Name: "foo", Current: FakeVersionToUpdate, Latest: TestBinaryVersion,
+			Status: OutdatedStatusOutdated}, entries[2])
+		assert.Equal(t, 0, dummyResolver.resolveCount, "outdated should never resolve a download url")
+		assert.Equal(t, 0, dummyState.saveCount)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, outdatedResult(entries), &exitErr)
+		assert.Equal(t, OutdatedExitCode, exitErr.Code)
+	})
+
+	t.Run("should only check given binaries", func(t *testing.T) {
+		dummyState := createFakeState(binaries)
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		entries, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: dummyState}, "foo/bar")
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.NoError(t, outdatedResult(entries))
+	})
+
+	t.Run("should report a constrained release tracked by its name as up to date", func(t *testing.T) {
+		binaryInfo := newTestBinary("Helm v3.15.0")
+		binaryInfo.Constraint = "~3.15"
+		dummyState := createFakeState([]dto.BinaryInfo{binaryInfo})
+		dummyResolver := &DummyResolver{versions: []string{"v3.14.0", "v3.15.0", "v4.0.0"}}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		entries, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: dummyState})
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "v3.15.0", entries[0].Latest)
+		assert.Equal(t, OutdatedStatusUpToDate, entries[0].Status)
+		assert.NoError(t, outdatedResult(entries))
+	})
+
+	t.Run("should not wait for a running install", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), state.StateFileName)
+		writer := state.NewState(path)
+		require.NoError(t, writer.Load())
+		writer.UpdateEntrie(newTestBinary(TestBinaryVersion))
+		require.NoError(t, writer.Save())
+		require.NoError(t, writer.Load(), "the lock should be held by the running install")
+		defer writer.Unlock()
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		entries, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: state.NewState(path)})
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, OutdatedStatusUpToDate, entries[0].Status)
+	})
+
+	t.Run("should return error when binary is not installed", func(t *testing.T) {
+		dummyState := createFakeState(binaries)
+
+		_, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: dummyState}, "unknown")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should report check failure", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{{FullName: "foo/foo", Name: "foo", Owner: "foo",
+			InstalledVersion: FakeVersionToUpdate, Resolver: "other"}})
+
+		entries, err := executeOutdatedCommand(t.Context(), OutdatedCommandConfig{azaState: dummyState})
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, OutdatedStatusError, entries[0].Status)
+		assert.NotEmpty(t, entries[0].Error)
+
+		err = outdatedResult(entries)
+		var exitErr *ExitError
+		assert.Error(t, err)
+		assert.NotErrorAs(t, err, &exitErr)
+	})
+}
+
+func TestFormatOutdated(t *testing.T) {
+	entries := []OutdatedEntry{
+		{Name: "foo", Current: "v0.0.1", Latest: "v0.0.2", Status: OutdatedStatusOutdated},
+		{Name: "foo/bar", Current: "v1.0.0", Constraint: "~1.0", Status: OutdatedStatusError, Error: "boom"},
+	}
+
+	t.Run("should format a table", func(t *testing.T) {
+		got, err := formatOutdated(entries, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "NAME      CURRENT   LATEST     STATUS\n"+
+			"foo       v0.0.1    v0.0.2     outdated\n"+
+			"foo/bar   v1.0.0    - (~1.0)   error: boom\n", got)
+	})
+
+	t.Run("should format json", func(t *testing.T) {
+		got, err := formatOutdated(entries, true)
+		require.NoError(t, err)
+
+		var decoded []OutdatedEntry
+		require.NoError(t, json.Unmarshal([]byte(got), &decoded))
+		assert.Equal(t, entries, decoded)
+	})
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	PinUseMessage   = "pin"
+	PinShortMessage = "pin a binary to its active (or given) version so update skips it"
+
+	PinArgsCountErrorMessage = "pin need a binary and an optional version, see above usage"
+)
+
+type PinCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+}
+
+func newPinCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
+	cfg := PinCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   PinUseMessage,
+		Short: PinShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 || len(args) > 2 {
+				_ = cmd.Help()
+				return errors.New(PinArgsCountErrorMessage)
+			}
+			version := ""
+			if len(args) == 2 {
+				version = args[1]
+			}
+			return executePinCommand(cmd.Context(), cfg, args[0], version)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	return cmd
+}
+
+func executePinCommand(ctx context.Context, cfg PinCommandConfig, binaryName, version string) error {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
+	if !ok {
+		return fmt.Errorf("binary %s is not installed (or not managed by azabox), use install command first",
+			binaryName)
+	}
+
+	if version != "" {
+		useCfg := UseCommandConfig{
+			azaInstaller: cfg.azaInstaller,
+			azaState:     cfg.azaState,
+		}
+		if err := switchVersion(ctx, &binaryInfo, version, useCfg); err != nil {
+			return err
+		}
+	}
+
+	logging.Logger().Debug("pin binary", "name", binaryInfo.DisplayName(), "version", binaryInfo.ActiveVersion)
+	binaryInfo.Pinned = true
+	fmt.Println(pinnedMessage(binaryInfo))
+
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	return cfg.azaState.Save()
+}
+
+// pinnedMessage reports the version a binary is pinned to, or would be in a dry run
+func pinnedMessage(binaryInfo dto.BinaryInfo) string {
+	if installer.DryRun {
+		return fmt.Sprintf("Would pin %s to version %s", binaryInfo.DisplayName(), binaryInfo.ActiveVersion)
+	}
+	return fmt.Sprintf("Pinned %s to version %s", binaryInfo.DisplayName(), binaryInfo.ActiveVersion)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func TestNewPinCommand(t *testing.T) {
+	t.Run("should create a new pin command", func(t *testing.T) {
+		cmd := newPinCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, PinUseMessage, cmd.Use)
+		assert.Equal(t, PinShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+
+	t.Run("should return an error when args count is wrong", func(t *testing.T) {
+		cmd := newPinCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+
+		for _, args := range [][]string{{}, {TestBinaryName, TestBinaryVersion, "extra"}} {
+			err := cmd.RunE(cmd, args)
+			require.Error(t, err)
+			assert.Equal(t, PinArgsCountErrorMessage, err.Error())
+		}
+	})
+}
+
+func TestExecutePinCommand(t *testing.T) {
+	t.Run("should pin the active version", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := PinCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executePinCommand(t.Context(), cfg, TestBinaryName, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, dummyInstaller.linkCount, "active version should not change")
+		assert.Equal(t, 1, dummyState.saveCount)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.True(t, info.Pinned)
+		assert.Equal(t, FakeVersionToUpdate, info.ActiveVersion)
+	})
+
+	t.Run("should switch to the given version before pinning", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.AddVersion(dto.VersionInfo{Version: TestBinaryVersion})
+		dummyState.UpdateEntrie(info)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{TestBinaryVersion}}
+		cfg := PinCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executePinCommand(t.Context(), cfg, TestBinaryName, TestBinaryVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.linkCount)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.True(t, info.Pinned)
+		assert.Equal(t, TestBinaryVersion, info.ActiveVersion)
+	})
+
+	t.Run("should not pin when switch fails", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.AddVersion(dto.VersionInfo{Version: TestBinaryVersion})
+		dummyState.UpdateEntrie(info)
+		cfg := PinCommandConfig{
+			azaInstaller: &DummyInstaller{onError: true, installedVersions: []string{TestBinaryVersion}},
+			azaState:     dummyState,
+		}
+
+		err := executePinCommand(t.Context(), cfg, TestBinaryName, TestBinaryVersion)
+
+		require.Error(t, err)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.False(t, info.Pinned)
+		assert.Equal(t, 0, dummyState.saveCount)
+	})
+
+	t.Run("should handle binary not in state", func(t *testing.T) {
+		cfg := PinCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)}),
+		}
+
+		err := executePinCommand(t.Context(), cfg, "unknown", "")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
+	})
+
+	t.Run("should handle error on state", func(t *testing.T) {
+		cfg := PinCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     &DummyState{onError: true},
+		}
+
+		err := executePinCommand(t.Context(), cfg, TestBinaryName, "")
+
+		require.Error(t, err)
+		assert.Equal(t, DummyStateErrorMessage, err.Error())
+	})
+}
+
+func TestPinnedMessage(t *testing.T) {
+	binaryInfo := newTestBinary(TestBinaryVersion)
+
+	t.Run("should report the pinned version", func(t *testing.T) {
+		assert.Equal(t, "Pinned "+binaryInfo.DisplayName()+" to version "+TestBinaryVersion, pinnedMessage(binaryInfo))
+	})
+
+	t.Run("should report what would be pinned in dry run", func(t *testing.T) {
+		installer.DryRun = true
+		defer func() { installer.DryRun = false }()
+
+		assert.Equal(t, "Would pin "+binaryInfo.DisplayName()+" to version "+TestBinaryVersion, pinnedMessage(binaryInfo))
+	})
+}
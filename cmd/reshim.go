@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	ReshimUseMessage   = "reshim"
+	ReshimShortMessage = "regenerate the link of every binary, shims when enabled in the config file"
+)
+
+type ReshimCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+	shims        bool
+}
+
+func newReshimCommand(azaInstaller installer.Installer, azaState state.State, shims bool) *cobra.Command {
+	cfg := ReshimCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+		shims:        shims,
+	}
+
+	cmd := &cobra.Command{
+		Use:   ReshimUseMessage,
+		Short: ReshimShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeReshimCommand(cfg)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	return cmd
+}
+
+func executeReshimCommand(cfg ReshimCommandConfig) error {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	entries := cfg.azaState.Entries()
+	report := newFailureReport("links", len(entries))
+	linked := 0
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		binaryInfo := entries[name]
+		if err := cfg.azaInstaller.Link(&binaryInfo, binaryInfo.ActiveVersion); err != nil {
+			report.add(binaryInfo.DisplayName(), err)
+			continue
+		}
+		linked++
+	}
+
+	kind := "links"
+	if cfg.shims {
+		kind = "shims"
+	}
+	fmt.Printf("Regenerated %d %s\n", linked, kind)
+	return report.err()
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+)
+
+func TestNewReshimCommand(t *testing.T) {
+	t.Run("should create a new reshim command", func(t *testing.T) {
+		cmd := newReshimCommand(&DummyInstaller{}, &DummyState{}, true)
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, ReshimUseMessage, cmd.Use)
+		assert.Equal(t, ReshimShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+}
+
+func TestExecuteReshimCommand(t *testing.T) {
+	t.Run("should link every binary", func(t *testing.T) {
+		dummyInstaller := &DummyInstaller{}
+		dummyState := createFakeState([]dto.BinaryInfo{
+			newTestBinary("v1.0.0"),
+			newNamedTestBinary("bar", "v2.0.0"),
+		})
+		cfg := ReshimCommandConfig{azaInstaller: dummyInstaller, azaState: dummyState, shims: true}
+
+		err := executeReshimCommand(cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, dummyInstaller.linkCount)
+		assert.Equal(t, 1, dummyState.loadCount)
+		assert.Equal(t, 0, dummyState.saveCount, "state should not be written")
+	})
+
+	t.Run("should report failed links", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{
+			newTestBinary("v1.0.0"),
+			newNamedTestBinary("bar", "v2.0.0"),
+		})
+		cfg := ReshimCommandConfig{azaInstaller: &DummyInstaller{onError: true}, azaState: dummyState}
+
+		err := executeReshimCommand(cfg)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 of 2 links failed")
+	})
+
+	t.Run("should handle state error", func(t *testing.T) {
+		dummyInstaller := &DummyInstaller{}
+		cfg := ReshimCommandConfig{azaInstaller: dummyInstaller, azaState: &DummyState{onError: true}}
+
+		err := executeReshimCommand(cfg)
+
+		require.Error(t, err)
+		assert.Equal(t, 0, dummyInstaller.linkCount)
+	})
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
 	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
 	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
@@ -17,20 +24,59 @@ const (
 of command-line binaries from GitHub, GitLab, or custom URLs.`
 )
 
+// timeout bounds the whole command, 0 disables it
+var timeout time.Duration
+
 var rootCmd = &cobra.Command{
 	Use:   RootUseMessage,
 	Short: RootShortMessage,
 	Long:  RootLongMessage,
 
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// a dry run resolves everything but never downloads nor writes the state
+		state.ReadOnly = installer.DryRun
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cmd.SetContext(ctx)
+			cobra.OnFinalize(cancel)
+		}
 		return logging.InitLogger()
 	},
 }
 
-func setupCommands() error {
+// setupCommands registers the commands, it returns the configuration used to run a binary through its shim
+// and whether shims are enabled
+func setupCommands() (ExecCommandConfig, bool, error) {
+	azaConfig, err := config.Load(filepath.Join(state.StateDirectory(), config.ConfigFileName))
+	if err != nil {
+		return ExecCommandConfig{}, false, err
+	}
+	httpclient.SetGitHubConfigToken(azaConfig.GitHub.Token)
+	cacheDir := filepath.Join(state.StateDirectory(), CacheDirName)
+	httpclient.SetCacheDir(cacheDir)
 	azaInstaller, err := installer.New()
 	if err != nil {
-		return err
+		return ExecCommandConfig{}, false, err
+	}
+	maxCacheSize, err := azaConfig.Cache.MaxSizeBytes()
+	if err != nil {
+		return ExecCommandConfig{}, false, err
+	}
+	downloadCache := installer.NewDownloadCache(cacheDir, maxCacheSize)
+	azaInstaller.WithConfig(azaConfig).WithDownloadCache(downloadCache)
+	// the cache is pruned once the command is over, never while a concurrent install may copy a download
+	// out of it, like filling the cache this is best effort
+	cobra.OnFinalize(func() {
+		if !installer.DryRun {
+			_ = downloadCache.Trim()
+		}
+	})
+	if azaConfig.Shims {
+		executable, err := os.Executable()
+		if err != nil {
+			return ExecCommandConfig{}, false, err
+		}
+		azaInstaller.WithShimTarget(executable)
 	}
 	azaState := state.NewState(filepath.Clean(
 		filepath.Join(state.StateDirectory(), state.StateFileName)))
@@ -38,8 +84,17 @@ func setupCommands() error {
 	rootCmd.AddCommand(newInstallCommand(azaInstaller, azaState))
 	rootCmd.AddCommand(newListCommand(azaState))
 	rootCmd.AddCommand(newUpdateCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newUninstallCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newUseCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newPinCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newUnpinCommand(azaState))
+	rootCmd.AddCommand(newOutdatedCommand(azaState))
+	rootCmd.AddCommand(newSyncCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newExecCommand(azaInstaller, azaState))
+	rootCmd.AddCommand(newReshimCommand(azaInstaller, azaState, azaConfig.Shims))
+	rootCmd.AddCommand(newCacheCommand(cacheDir, downloadCache, maxCacheSize))
 
-	return nil
+	return ExecCommandConfig{azaInstaller: azaInstaller, azaState: azaState}, azaConfig.Shims, nil
 }
 
 func Execute() error {
@@ -49,11 +104,27 @@ func Execute() error {
 		}
 	}()
 
-	if err := setupCommands(); err != nil {
+	shimCfg, shims, err := setupCommands()
+	if err != nil {
 		return err
 	}
+	// invoked through a shim, azabox runs the selected version of the binary instead of a command
+	if name := filepath.Base(os.Args[0]); shims && name != RootUseMessage {
+		if handled, err := executeShim(shimCfg, name, os.Args[1:]); handled {
+			return err
+		}
+	}
+
+	// the first interrupt cancels the running command so downloads are cleaned up and the state
+	// lock is released, the default behaviour is restored so a second interrupt kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		return err
 	}
 	return nil
@@ -62,6 +133,16 @@ func Execute() error {
 func init() {
 	rootCmd.PersistentFlags().StringVar(&logging.LogLevel, "log-level", "",
 		"Set the logging level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&installer.RequireChecksum, "require-checksum", false,
+		"refuse to install assets without a published checksum")
+	rootCmd.PersistentFlags().BoolVar(&installer.DryRun, "dry-run", false,
+		"show what would be installed or changed without downloading nor writing anything")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0,
+		"abort the command after the given duration (e.g. 30s, 5m), disabled by default")
+	rootCmd.PersistentFlags().BoolVar(&httpclient.Refresh, "refresh", false,
+		"ignore the cached release metadata and fetch them again")
+	rootCmd.PersistentFlags().BoolVar(&httpclient.Offline, "offline", false,
+		"never access the network, only use the cached release metadata and downloads")
 
 	// initialize the registry with default resolvers
 	_ = resolver.GetRegistryResolver().WithDefaultResolvers()
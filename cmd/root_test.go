@@ -19,7 +19,7 @@ func TestRootCmd(t *testing.T) {
 		err := Execute()
 		assert.NoError(t, err)
 		resolvers := resolver.GetRegistryResolver().GetResolvers()
-		assert.Len(t, resolvers, 1)
+		assert.Len(t, resolvers, 3)
 	})
 
 	t.Run("should set logLevel when flag is used", func(t *testing.T) {
@@ -29,6 +29,29 @@ func TestRootCmd(t *testing.T) {
 		assert.Equal(t, "debug", azalogger.DebugLevel.String())
 	})
 
+	t.Run("should bound the command with a timeout", func(t *testing.T) {
+		flag := rootCmd.PersistentFlags().Lookup("timeout")
+		require.NotNil(t, flag)
+		assert.Equal(t, "0s", flag.DefValue, "timeout should be disabled by default")
+
+		var deadline bool
+		fakeCmd := &cobra.Command{
+			Use: "fake",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				_, deadline = cmd.Context().Deadline()
+				return nil
+			},
+		}
+		rootCmd.AddCommand(fakeCmd)
+		defer rootCmd.RemoveCommand(fakeCmd)
+		rootCmd.SetArgs([]string{"fake", "--timeout", "1m"})
+
+		err := rootCmd.ExecuteContext(t.Context())
+
+		require.NoError(t, err)
+		assert.True(t, deadline)
+	})
+
 	t.Run("should handle error", func(t *testing.T) {
 		expectedContains := "some error"
 		saveRootCmd := rootCmd
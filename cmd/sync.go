@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/manifest"
+	"gitlab.com/ludovic-alarcon/azabox/internal/semver"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	SyncUseMessage   = "sync"
+	SyncShortMessage = "install, update and optionally remove binaries to match the azabox.yaml manifest"
+)
+
+type SyncCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+	manifestPath string
+	prune        bool
+}
+
+type syncAction string
+
+const (
+	syncInstall syncAction = "+"
+	syncUpdate  syncAction = "~"
+	syncRemove  syncAction = "-"
+)
+
+// syncChange is a step of the plan bringing the state to the manifest,
+// wanted is the binary as listed in the manifest and current its state entry
+type syncChange struct {
+	action  syncAction
+	wanted  dto.BinaryInfo
+	current dto.BinaryInfo
+	reason  string
+}
+
+func (c syncChange) String() string {
+	switch c.action {
+	case syncInstall:
+		return fmt.Sprintf("  + %s %s", c.wanted.DisplayName(), c.wanted.Version)
+	case syncUpdate:
+		return fmt.Sprintf("  ~ %s %s -> %s (%s)", c.current.DisplayName(), c.current.ActiveVersion,
+			c.wanted.Version, c.reason)
+	default:
+		return fmt.Sprintf("  - %s %s", c.current.DisplayName(), c.current.ActiveVersion)
+	}
+}
+
+// syncPlan lists the changes in manifest order, removals last
+type syncPlan struct {
+	changes  []syncChange
+	upToDate int
+	unlisted []string
+}
+
+func newSyncCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
+	cfg := SyncCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   SyncUseMessage,
+		Short: SyncShortMessage,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeSyncCommand(cmd.Context(), cfg)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVarP(&cfg.manifestPath, "file", "f", manifest.FileName, "path of the manifest")
+	cmd.Flags().BoolVar(&cfg.prune, "prune", false, "uninstall the binaries not listed in the manifest")
+
+	return cmd
+}
+
+// binariesInfoFromManifest builds the binaries like install does from its arguments and flags
+func binariesInfoFromManifest(m manifest.Manifest) ([]dto.BinaryInfo, error) {
+	binariesInfo := make([]dto.BinaryInfo, 0, len(m.Tools))
+	for _, tool := range m.Tools {
+		version := tool.Version
+		if version == "" {
+			version = DefaultBinaryVersion
+		}
+		binaryInfo, err := withSource(withUrlTemplate(binariesInfoFromArgs([]string{tool.Name}, version),
+			tool.URL, tool.LatestURL, tool.Vars), tool.Source)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", tool.Name, err)
+		}
+		binariesInfo = append(binariesInfo, binaryInfo...)
+	}
+	return binariesInfo, nil
+}
+
+func planSync(wanted []dto.BinaryInfo, entries map[string]dto.BinaryInfo, prune bool) syncPlan {
+	var plan syncPlan
+	listed := make(map[string]bool, len(wanted))
+	for _, binaryInfo := range wanted {
+		listed[binaryInfo.FullName] = true
+		current, ok := entries[binaryInfo.FullName]
+		if !ok {
+			plan.changes = append(plan.changes, syncChange{action: syncInstall, wanted: binaryInfo})
+			continue
+		}
+		if reason := syncDrift(binaryInfo, current); reason != "" {
+			plan.changes = append(plan.changes,
+				syncChange{action: syncUpdate, wanted: binaryInfo, current: current, reason: reason})
+			continue
+		}
+		plan.upToDate++
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		if listed[name] {
+			continue
+		}
+		if prune {
+			plan.changes = append(plan.changes, syncChange{action: syncRemove, current: entries[name]})
+		} else {
+			plan.unlisted = append(plan.unlisted, entries[name].DisplayName())
+		}
+	}
+	return plan
+}
+
+// syncDrift explains how an installed binary differs from the manifest, empty when it matches.
+// A binary following the latest release is not updated, that is the job of the update command
+func syncDrift(wanted, current dto.BinaryInfo) string {
+	switch {
+	case wanted.Resolver != "" && wanted.Resolver != current.Resolver:
+		return fmt.Sprintf("source %s -> %s", current.Resolver, wanted.Resolver)
+	case wanted.URLTemplate != current.URLTemplate:
+		return "url changed"
+	case wanted.Constraint == "" && wanted.Version != DefaultBinaryVersion &&
+		!sameVersion(wanted.Version, current.ActiveVersion):
+		return fmt.Sprintf("version %s -> %s", current.ActiveVersion, wanted.Version)
+	case wanted.Constraint != "" && !matchConstraint(wanted.Constraint, current.ActiveVersion):
+		return fmt.Sprintf("version %s does not match %s", current.ActiveVersion, wanted.Constraint)
+	case wanted.Constraint != current.Constraint:
+		return fmt.Sprintf("constraint %s -> %s", constraintOrNone(current.Constraint),
+			constraintOrNone(wanted.Constraint))
+	}
+	return ""
+}
+
+func constraintOrNone(constraint string) string {
+	if constraint == "" {
+		return "none"
+	}
+	return constraint
+}
+
+func matchConstraint(rawConstraint, version string) bool {
+	constraint, err := semver.ParseConstraint(rawConstraint)
+	if err != nil {
+		return false
+	}
+	parsed, err := semver.Parse(version)
+	return err == nil && constraint.Match(parsed)
+}
+
+// sameVersion compares tags exactly apart from a leading "v", "1.2.3" in a manifest matches an installed "v1.2.3".
+// A GitHub release is tracked by its name, which ends with its tag (e.g. "Helm v3.15.0")
+func sameVersion(a, b string) bool {
+	return a == b || versionTag(a) == versionTag(b)
+}
+
+func versionTag(version string) string {
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[len(fields)-1], "v")
+}
+
+func (p syncPlan) print(manifestPath string) {
+	if len(p.changes) == 0 {
+		fmt.Printf("Everything is in sync with %s\n", manifestPath)
+	} else {
+		fmt.Printf("Sync plan from %s:\n", manifestPath)
+		counts := make(map[syncAction]int, 3)
+		for _, change := range p.changes {
+			fmt.Println(change)
+			counts[change.action]++
+		}
+		fmt.Printf("Plan: %d to install, %d to update, %d to remove, %d up to date\n",
+			counts[syncInstall], counts[syncUpdate], counts[syncRemove], p.upToDate)
+	}
+	if len(p.unlisted) > 0 {
+		fmt.Printf("Not listed in the manifest, use --prune to remove them: %s\n", strings.Join(p.unlisted, ", "))
+	}
+}
+
+// executeSyncCommand prints the plan then applies every change, the ones applied are saved even if others failed
+func executeSyncCommand(ctx context.Context, cfg SyncCommandConfig) error {
+	azaManifest, err := manifest.Load(cfg.manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("manifest %s not found", cfg.manifestPath)
+	}
+	if err != nil {
+		return err
+	}
+	wanted, err := binariesInfoFromManifest(azaManifest)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	plan := planSync(wanted, cfg.azaState.Entries(), cfg.prune)
+	plan.print(cfg.manifestPath)
+	if installer.DryRun {
+		return cfg.azaState.Save()
+	}
+
+	report := newFailureReport("changes", len(plan.changes))
+	for _, change := range plan.changes {
+		name := change.wanted.DisplayName()
+		if change.action == syncRemove {
+			name = change.current.DisplayName()
+		}
+		if err := ctx.Err(); err != nil {
+			report.add(name, err)
+			continue
+		}
+		if err := applySyncChange(ctx, change, cfg); err != nil {
+			report.add(name, err)
+		}
+	}
+
+	if err := cfg.azaState.Save(); err != nil {
+		return err
+	}
+	lockPath := filepath.Join(filepath.Dir(cfg.manifestPath), manifest.LockFileName)
+	if err := writeLock(lockPath, wanted, cfg.azaState.Entries()); err != nil {
+		return fmt.Errorf("cannot write %s: %w", lockPath, err)
+	}
+	return report.err()
+}
+
+// writeLock records the active version of every installed binary of the manifest,
+// the lockfile is only rewritten when it changed
+func writeLock(lockPath string, wanted []dto.BinaryInfo, entries map[string]dto.BinaryInfo) error {
+	var lock manifest.Lock
+	for _, binaryInfo := range wanted {
+		entry, ok := entries[binaryInfo.FullName]
+		if !ok {
+			continue
+		}
+		versionInfo, _ := entry.FindVersion(entry.ActiveVersion)
+		if versionInfo.URL == "" {
+			// installed by an azabox release that did not track the asset url
+			logging.Logger().Debug("cannot lock binary without url", "name", entry.FullName)
+			continue
+		}
+		lock.Tools = append(lock.Tools, manifest.LockedTool{
+			Name:     entry.FullName,
+			Version:  entry.ActiveVersion,
+			Resolver: entry.Resolver,
+			URL:      versionInfo.URL,
+			SHA256:   versionInfo.SHA256,
+			// how it is resolved, a frozen install may be the first one on a machine
+			Constraint:       entry.Constraint,
+			URLTemplate:      entry.URLTemplate,
+			TemplateVars:     entry.TemplateVars,
+			LatestVersionURL: entry.LatestVersionURL,
+			// the release files are locked as well, a frozen install does not resolve again
+			ChecksumURL:   versionInfo.ChecksumURL,
+			SignatureURLs: versionInfo.SignatureURLs,
+			AssetAPIURLs:  versionInfo.AssetAPIURLs,
+		})
+	}
+	slices.SortFunc(lock.Tools, func(a, b manifest.LockedTool) int { return strings.Compare(a.Name, b.Name) })
+
+	previous, err := manifest.LoadLock(lockPath)
+	if err == nil && slices.EqualFunc(previous.Tools, lock.Tools, manifest.LockedTool.Equal) {
+		return nil
+	}
+	if err := lock.Save(lockPath); err != nil {
+		return err
+	}
+	fmt.Printf("Locked %d binaries in %s\n", len(lock.Tools), lockPath)
+	return nil
+}
+
+func applySyncChange(ctx context.Context, change syncChange, cfg SyncCommandConfig) error {
+	logging.Logger().Debug("sync binary", "action", change.action, "name", change.wanted.FullName,
+		"version", change.wanted.Version, "reason", change.reason)
+
+	switch change.action {
+	case syncInstall:
+		return installBinary(ctx, &change.wanted, InstallCommandConfig{
+			azaInstaller: cfg.azaInstaller,
+			azaState:     cfg.azaState,
+		})
+	case syncRemove:
+		return uninstallAll(change.current, UninstallCommandConfig{
+			azaInstaller: cfg.azaInstaller,
+			azaState:     cfg.azaState,
+		})
+	}
+
+	// the entry takes the source and constraint of the manifest, then switches to the wanted version
+	binaryInfo := change.current
+	if change.wanted.Resolver != "" {
+		binaryInfo.Resolver = change.wanted.Resolver
+	}
+	binaryInfo.URLTemplate = change.wanted.URLTemplate
+	binaryInfo.TemplateVars = change.wanted.TemplateVars
+	binaryInfo.LatestVersionURL = change.wanted.LatestVersionURL
+	binaryInfo.Constraint = change.wanted.Constraint
+	binaryInfo.Version = change.wanted.Version
+
+	version := change.wanted.Version
+	if version == DefaultBinaryVersion || binaryInfo.Constraint != "" {
+		latest, _, err := resolveLatestVersion(ctx, binaryInfo)
+		if err != nil {
+			return err
+		}
+		version = latest
+	}
+	err := switchVersion(ctx, &binaryInfo, version, UseCommandConfig{
+		azaInstaller: cfg.azaInstaller,
+		azaState:     cfg.azaState,
+	})
+	if err != nil {
+		return err
+	}
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	return nil
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/manifest"
+	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+)
+
+func writeTestManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), manifest.FileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewSyncCommand(t *testing.T) {
+	t.Run("should create a new sync command", func(t *testing.T) {
+		cmd := newSyncCommand(&DummyInstaller{}, &DummyState{})
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, SyncUseMessage, cmd.Use)
+		assert.Equal(t, SyncShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+		assert.Equal(t, manifest.FileName, cmd.Flags().Lookup("file").DefValue)
+		assert.NotNil(t, cmd.Flags().Lookup("prune"))
+	})
+}
+
+func TestPlanSync(t *testing.T) {
+	t.Run("should plan installs, updates and removals", func(t *testing.T) {
+		wanted := binariesInfoFromArgs([]string{"new", "drifted", "constrained", "same"}, DefaultBinaryVersion)
+		wanted[1].Version = "v2.0.0"
+		wanted[2] = createBinaryInfo("constrained", "~1.2")
+		entries := createFakeState([]dto.BinaryInfo{
+			newNamedTestBinary("drifted", "v1.0.0"),
+			newNamedTestBinary("constrained", "v1.1.0"),
+			newNamedTestBinary("same", "v1.0.0"),
+			newNamedTestBinary("unlisted", "v1.0.0"),
+		}).Entries()
+
+		plan := planSync(wanted, entries, true)
+
+		require.Len(t, plan.changes, 4)
+		assert.Equal(t, "  + new latest", plan.changes[0].String())
+		assert.Equal(t, "  ~ drifted v1.0.0 -> v2.0.0 (version v1.0.0 -> v2.0.0)", plan.changes[1].String())
+		assert.Equal(t, "  ~ constrained v1.1.0 -> ~1.2 (version v1.1.0 does not match ~1.2)",
+			plan.changes[2].String())
+		assert.Equal(t, "  - unlisted v1.0.0", plan.changes[3].String())
+		assert.Equal(t, 1, plan.upToDate)
+		assert.Empty(t, plan.unlisted)
+	})
+
+	t.Run("should only report unlisted binaries without prune", func(t *testing.T) {
+		entries := createFakeState([]dto.BinaryInfo{newNamedTestBinary("unlisted", "v1.0.0")}).Entries()
+
+		plan := planSync(nil, entries, false)
+
+		assert.Empty(t, plan.changes)
+		assert.Equal(t, []string{"unlisted"}, plan.unlisted)
+	})
+}
+
+func TestSyncDrift(t *testing.T) {
+	current := newTestBinary("v1.2.3")
+	current.Constraint = "~1.2"
+	testCases := []struct {
+		name     string
+		wanted   dto.BinaryInfo
+		expected string
+	}{
+		{name: "should match same constraint", wanted: createBinaryInfo(TestBinaryName, "~1.2")},
+		{
+			name:     "should detect new constraint",
+			wanted:   createBinaryInfo(TestBinaryName, "1.x"),
+			expected: "constraint ~1.2 -> 1.x",
+		},
+		{
+			name:     "should detect constraint removal",
+			wanted:   createBinaryInfo(TestBinaryName, DefaultBinaryVersion),
+			expected: "constraint ~1.2 -> none",
+		},
+		{
+			name:     "should detect version outside constraint",
+			wanted:   createBinaryInfo(TestBinaryName, "~1.3"),
+			expected: "version v1.2.3 does not match ~1.3",
+		},
+		{
+			name:     "should detect source change",
+			wanted:   dto.BinaryInfo{FullName: TestBinaryFullName, Version: "~1.2", Resolver: "gitlab"},
+			expected: "source dummy -> gitlab",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, syncDrift(tc.wanted, current))
+		})
+	}
+
+	t.Run("should match exact version loosely", func(t *testing.T) {
+		current := newTestBinary("v1.2.3")
+
+		assert.Empty(t, syncDrift(createBinaryInfo(TestBinaryName, "1.2.3"), current))
+	})
+
+	t.Run("should report a drift on any version segment", func(t *testing.T) {
+		current := newTestBinary("2024.1.2.3")
+
+		assert.Equal(t, "version 2024.1.2.3 -> 2024.1.2.9",
+			syncDrift(createBinaryInfo(TestBinaryName, "2024.1.2.9"), current))
+	})
+}
+
+func TestSameVersion(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"v1.2.3", "v1.2.3", true},
+		{"1.2.3", "v1.2.3", true},
+		{"Helm v3.15.0", "v3.15.0", true},
+		{"1.2.3.4", "1.2.3.5", false},
+		{"2024.1.2.3", "2024.1.2.9", false},
+		{"v1.2.3", "v1.2.3-rc.1", false},
+		{"tool-1.2.3", "1.2.3", false},
+		{"", "v1.2.3", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sameVersion(tc.a, tc.b))
+			assert.Equal(t, tc.expected, sameVersion(tc.b, tc.a))
+		})
+	}
+}
+
+func TestExecuteSyncCommand(t *testing.T) {
+	t.Run("should install missing and remove unlisted binaries", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		dummyState := createFakeState([]dto.BinaryInfo{newNamedTestBinary("unlisted", "v1.0.0")})
+		dummyInstaller := &DummyInstaller{}
+		cfg := SyncCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+			manifestPath: writeTestManifest(t, "tools:\n  - name: foo\n    source: dummy\n"),
+			prune:        true,
+		}
+
+		err := executeSyncCommand(t.Context(), cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		assert.Equal(t, 1, dummyInstaller.unlinkCount)
+		assert.True(t, dummyState.Has(TestBinaryFullName))
+		assert.False(t, dummyState.Has("unlisted/unlisted"))
+		assert.Equal(t, 1, dummyState.saveCount)
+		lock, err := manifest.LoadLock(filepath.Join(filepath.Dir(cfg.manifestPath), manifest.LockFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []manifest.LockedTool{{
+			Name:     TestBinaryFullName,
+			Version:  TestBinaryVersion,
+			Resolver: DummyResolverName,
+			URL:      TestResolvedURL,
+		}}, lock.Tools)
+	})
+
+	t.Run("should lock the release files of the active version", func(t *testing.T) {
+		binaryInfo := newTestBinary(TestBinaryVersion)
+		binaryInfo.Versions[0].URL = TestResolvedURL
+		binaryInfo.Versions[0].ChecksumURL = "https://fake.url/checksums.txt"
+		binaryInfo.Versions[0].SignatureURLs = []string{"https://fake.url/checksums.txt.sig"}
+		binaryInfo.Versions[0].AssetAPIURLs = map[string]string{TestResolvedURL: "https://api.fake.url/assets/1"}
+		lockPath := filepath.Join(t.TempDir(), manifest.LockFileName)
+
+		err := writeLock(lockPath, []dto.BinaryInfo{binaryInfo},
+			map[string]dto.BinaryInfo{TestBinaryFullName: binaryInfo})
+
+		require.NoError(t, err)
+		lock, err := manifest.LoadLock(lockPath)
+		require.NoError(t, err)
+		require.Len(t, lock.Tools, 1)
+		assert.Equal(t, "https://fake.url/checksums.txt", lock.Tools[0].ChecksumURL)
+		assert.Equal(t, binaryInfo.Versions[0].SignatureURLs, lock.Tools[0].SignatureURLs)
+		assert.Equal(t, binaryInfo.Versions[0].AssetAPIURLs, lock.Tools[0].AssetAPIURLs)
+	})
+
+	t.Run("should lock how the binary is resolved", func(t *testing.T) {
+		binaryInfo := newTestBinary(TestBinaryVersion)
+		binaryInfo.Versions[0].URL = TestResolvedURL
+		binaryInfo.Constraint = "~1.0"
+		binaryInfo.URLTemplate = "https://fake.url/{{.Version}}/{{.Name}}"
+		binaryInfo.TemplateVars = map[string]string{"Flavor": "static"}
+		binaryInfo.LatestVersionURL = "https://fake.url/stable.txt"
+		lockPath := filepath.Join(t.TempDir(), manifest.LockFileName)
+
+		err := writeLock(lockPath, []dto.BinaryInfo{binaryInfo},
+			map[string]dto.BinaryInfo{TestBinaryFullName: binaryInfo})
+
+		require.NoError(t, err)
+		lock, err := manifest.LoadLock(lockPath)
+		require.NoError(t, err)
+		require.Len(t, lock.Tools, 1)
+		assert.Equal(t, "~1.0", lock.Tools[0].Constraint)
+		assert.Equal(t, binaryInfo.URLTemplate, lock.Tools[0].URLTemplate)
+		assert.Equal(t, binaryInfo.TemplateVars, lock.Tools[0].TemplateVars)
+		assert.Equal(t, binaryInfo.LatestVersionURL, lock.Tools[0].LatestVersionURL)
+	})
+
+	t.Run("should switch a drifted binary to the manifest version", func(t *testing.T) {
+		dummyResolver := &DummyResolver{}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := SyncCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+			manifestPath: writeTestManifest(t, "tools:\n  - name: foo\n    version: "+TestBinaryVersion+"\n"),
+		}
+
+		err := executeSyncCommand(t.Context(), cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{TestBinaryVersion}, dummyResolver.resolvedVersions)
+		assert.Equal(t, 1, dummyInstaller.linkCount)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, TestBinaryVersion, info.ActiveVersion)
+		assert.Len(t, info.Versions, 2)
+	})
+
+	t.Run("should not change anything in dry run", func(t *testing.T) {
+		installer.DryRun = true
+		defer func() { installer.DryRun = false }()
+		dummyState := createFakeState([]dto.BinaryInfo{newNamedTestBinary("unlisted", "v1.0.0")})
+		dummyInstaller := &DummyInstaller{}
+		cfg := SyncCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+			manifestPath: writeTestManifest(t, "tools:\n  - name: foo\n"),
+			prune:        true,
+		}
+
+		err := executeSyncCommand(t.Context(), cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, dummyInstaller.installCount)
+		assert.Equal(t, 0, dummyInstaller.uninstallCount)
+		assert.True(t, dummyState.Has("unlisted/unlisted"))
+		assert.NoFileExists(t, filepath.Join(filepath.Dir(cfg.manifestPath), manifest.LockFileName))
+	})
+
+	t.Run("should report failed changes", func(t *testing.T) {
+		dummyResolver := &DummyResolver{onError: true}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+		dummyState := createFakeState([]dto.BinaryInfo{newNamedTestBinary("unlisted", "v1.0.0")})
+		cfg := SyncCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+			manifestPath: writeTestManifest(t, "tools:\n  - name: foo\n    source: dummy\n"),
+			prune:        true,
+		}
+
+		err := executeSyncCommand(t.Context(), cfg)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, PartialFailureExitCode, exitErr.Code)
+		assert.Contains(t, err.Error(), "1 of 2 changes failed")
+		assert.False(t, dummyState.Has("unlisted/unlisted"), "successful removal should be saved")
+		assert.Equal(t, 1, dummyState.saveCount)
+	})
+
+	t.Run("should handle missing manifest", func(t *testing.T) {
+		dummyState := createFakeState(nil)
+		cfg := SyncCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+			manifestPath: filepath.Join(t.TempDir(), manifest.FileName),
+		}
+
+		err := executeSyncCommand(t.Context(), cfg)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+		assert.Equal(t, 0, dummyState.loadCount, "state should not be loaded")
+	})
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	UninstallUseMessage   = "uninstall"
+	UninstallShortMessage = "uninstall binaries (or a specific version) for current user"
+
+	UninstallArgsCountErrorMessage = "uninstall need at least one argument, see above usage"
+)
+
+type UninstallCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+}
+
+func newUninstallCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
+	cfg := UninstallCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   UninstallUseMessage,
+		Short: UninstallShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				_ = cmd.Help()
+				return errors.New(UninstallArgsCountErrorMessage)
+			}
+			return executeUninstallCommand(cfg, args...)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	return cmd
+}
+
+// parseBinaryArg splits a "<binary>@<version>" argument, version is empty when not provided
+// but an "@" without a version is refused rather than read as every version
+func parseBinaryArg(arg string) (string, string, error) {
+	name, version, found := strings.Cut(arg, "@")
+	if found && version == "" {
+		return "", "", fmt.Errorf("missing version after @ in %s", arg)
+	}
+	return name, version, nil
+}
+
+func executeUninstallCommand(cfg UninstallCommandConfig, args ...string) error {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	report := newFailureReport("uninstalls", len(args))
+	for _, arg := range args {
+		binaryName, version, err := parseBinaryArg(arg)
+		if err != nil {
+			report.add(arg, err)
+			continue
+		}
+		binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
+		if !ok {
+			report.add(binaryName, fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName))
+			continue
+		}
+		if err := uninstall(binaryInfo, version, cfg); err != nil {
+			report.add(arg, err)
+		}
+	}
+
+	// what was removed before a failure is gone from disk, the state must not track it anymore
+	if err := cfg.azaState.Save(); err != nil {
+		return err
+	}
+	return report.err()
+}
+
+func uninstall(binaryInfo dto.BinaryInfo, version string, cfg UninstallCommandConfig) error {
+	logging.Logger().Debug("uninstall binary", "name", binaryInfo.DisplayName(),
+		"version", version, "activeVersion", binaryInfo.ActiveVersion)
+
+	if version == "" {
+		return uninstallAll(binaryInfo, cfg)
+	}
+
+	if _, ok := binaryInfo.FindVersion(version); !ok {
+		// not tracked, but it may still be a leftover of a previous azabox release
+		if err := cfg.azaInstaller.Uninstall(&binaryInfo, version); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("version %s of %s is not installed", version, binaryInfo.DisplayName())
+			}
+			return err
+		}
+		fmt.Println(uninstalledMessage(binaryInfo.DisplayName() + " version " + version))
+		return nil
+	}
+
+	if len(binaryInfo.Versions) == 1 {
+		return uninstallAll(binaryInfo, cfg)
+	}
+
+	err := cfg.azaInstaller.Uninstall(&binaryInfo, version)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	binaryInfo.RemoveVersion(version)
+	if version == binaryInfo.InstalledVersion {
+		binaryInfo.InstalledVersion = binaryInfo.LastInstalledVersion()
+	}
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	fmt.Println(uninstalledMessage(binaryInfo.DisplayName() + " version " + version))
+
+	if version == binaryInfo.ActiveVersion {
+		if err := cfg.azaInstaller.Link(&binaryInfo, binaryInfo.InstalledVersion); err != nil {
+			return err
+		}
+		binaryInfo.ActiveVersion = binaryInfo.InstalledVersion
+		cfg.azaState.UpdateEntrie(binaryInfo)
+		// a dry run link already reported the switch
+		if !installer.DryRun {
+			fmt.Printf("Switched %s to version %s\n", binaryInfo.DisplayName(), binaryInfo.ActiveVersion)
+		}
+	}
+	return nil
+}
+
+func uninstallAll(binaryInfo dto.BinaryInfo, cfg UninstallCommandConfig) error {
+	// the active version goes last, a failure on another version leaves the link working
+	versions := slices.Clone(binaryInfo.Versions)
+	isActive := func(v dto.VersionInfo) bool { return v.Version == binaryInfo.ActiveVersion }
+	if i := slices.IndexFunc(versions, isActive); i >= 0 {
+		versions = append(slices.Delete(versions, i, i+1), binaryInfo.Versions[i])
+	}
+	for _, versionInfo := range versions {
+		err := cfg.azaInstaller.Uninstall(&binaryInfo, versionInfo.Version)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			// keep tracking the versions left only
+			if _, ok := binaryInfo.FindVersion(binaryInfo.InstalledVersion); !ok {
+				binaryInfo.InstalledVersion = binaryInfo.LastInstalledVersion()
+			}
+			cfg.azaState.UpdateEntrie(binaryInfo)
+			return err
+		}
+		binaryInfo.RemoveVersion(versionInfo.Version)
+	}
+	// every version is gone, the entry is removed even when the link could not be
+	cfg.azaState.RemoveEntry(binaryInfo.FullName)
+	if err := cfg.azaInstaller.Unlink(&binaryInfo); err != nil {
+		return err
+	}
+	fmt.Println(uninstalledMessage(binaryInfo.DisplayName()))
+	return nil
+}
+
+// uninstalledMessage reports what has been uninstalled, or what would be in a dry run
+func uninstalledMessage(what string) string {
+	if installer.DryRun {
+		return "Would uninstall " + what
+	}
+	return "Uninstalled " + what
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func TestNewUninstallCommand(t *testing.T) {
+	t.Run("should create a new uninstall command", func(t *testing.T) {
+		cmd := newUninstallCommand(&DummyInstaller{}, &DummyState{})
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, UninstallUseMessage, cmd.Use)
+		assert.Equal(t, UninstallShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+
+	t.Run("should return an error when no args provided", func(t *testing.T) {
+		cmd := newUninstallCommand(&DummyInstaller{}, &DummyState{})
+
+		err := cmd.RunE(cmd, []string{})
+		require.Error(t, err)
+		assert.Equal(t, UninstallArgsCountErrorMessage, err.Error())
+	})
+}
+
+func TestParseBinaryArg(t *testing.T) {
+	testCases := []struct {
+		name            string
+		arg             string
+		expectedName    string
+		expectedVersion string
+	}{
+		{name: "name only", arg: "foo", expectedName: "foo", expectedVersion: ""},
+		{name: "full name only", arg: "foo/bar", expectedName: "foo/bar", expectedVersion: ""},
+		{name: "name and version", arg: "foo@v1.0.0", expectedName: "foo", expectedVersion: "v1.0.0"},
+		{name: "full name and version", arg: "foo/bar@1.2.3", expectedName: "foo/bar", expectedVersion: "1.2.3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, version, err := parseBinaryArg(tc.arg)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedName, name)
+			assert.Equal(t, tc.expectedVersion, version)
+		})
+	}
+
+	t.Run("should refuse a missing version after @", func(t *testing.T) {
+		_, _, err := parseBinaryArg("foo@")
+
+		require.Error(t, err)
+		assert.Equal(t, "missing version after @ in foo@", err.Error())
+	})
+}
+
+func TestExecuteUninstallCommand(t *testing.T) {
+	t.Run("should remove binary, symlink and state entry", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.uninstallCount)
+		assert.Equal(t, 1, dummyInstaller.unlinkCount)
+		assert.Equal(t, 1, dummyState.saveCount)
+		assert.False(t, dummyState.Has(TestBinaryFullName), "binary should be removed from state")
+	})
+
+	t.Run("should remove the whole binary when the installed version is given", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName+"@"+TestBinaryVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.unlinkCount)
+		assert.False(t, dummyState.Has(TestBinaryFullName), "binary should be removed from state")
+	})
+
+	t.Run("should remove an untracked version leftover", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName+"@"+FakeVersionToUpdate)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.uninstallCount)
+		assert.Equal(t, 0, dummyInstaller.unlinkCount, "symlink should be left untouched")
+		assert.True(t, dummyState.Has(TestBinaryFullName), "binary should still be in state")
+	})
+
+	t.Run("should repoint symlink when removing the active version", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.ActiveVersion = FakeVersionToUpdate
+		info.AddVersion(dto.VersionInfo{Version: FakeVersionToUpdate})
+		dummyState.UpdateEntrie(info)
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName+"@"+FakeVersionToUpdate)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyInstaller.uninstallCount)
+		assert.Equal(t, 1, dummyInstaller.linkCount)
+		assert.Equal(t, 0, dummyInstaller.unlinkCount)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, TestBinaryVersion, info.ActiveVersion)
+		assert.Len(t, info.Versions, 1)
+		_, ok := info.FindVersion(FakeVersionToUpdate)
+		assert.False(t, ok, "removed version should not be tracked anymore")
+	})
+
+	t.Run("should remove every tracked version", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.AddVersion(dto.VersionInfo{Version: FakeVersionToUpdate})
+		dummyState.UpdateEntrie(info)
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, dummyInstaller.uninstallCount)
+		assert.Equal(t, 1, dummyInstaller.unlinkCount)
+		assert.False(t, dummyState.Has(TestBinaryFullName), "binary should be removed from state")
+	})
+
+	t.Run("should handle binary not in state", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		cfg := UninstallCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, "unknown")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
+		assert.True(t, dummyState.Has(TestBinaryFullName), "binary should still be in state")
+	})
+
+	t.Run("should save what was removed before a failure", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		cfg := UninstallCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName, "unknown")
+
+		require.Error(t, err)
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, PartialFailureExitCode, exitErr.Code)
+		assert.Contains(t, err.Error(), "1 of 2 uninstalls failed")
+		assert.False(t, dummyState.Has(TestBinaryFullName), "binary should be removed from state")
+		assert.Equal(t, 1, dummyState.saveCount)
+	})
+
+	t.Run("should refuse a missing version after @", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := UninstallCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName+"@")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing version after @")
+		assert.Equal(t, 0, dummyInstaller.uninstallCount)
+		assert.True(t, dummyState.Has(TestBinaryFullName), "binary should still be in state")
+	})
+
+	t.Run("should handle error on state", func(t *testing.T) {
+		dummyState := &DummyState{onError: true}
+		cfg := UninstallCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName)
+
+		require.Error(t, err)
+		assert.Equal(t, DummyStateErrorMessage, err.Error())
+	})
+
+	t.Run("should handle error in installer", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(TestBinaryVersion)})
+		cfg := UninstallCommandConfig{
+			azaInstaller: &DummyInstaller{onError: true},
+			azaState:     dummyState,
+		}
+
+		err := executeUninstallCommand(cfg, TestBinaryName)
+
+		require.Error(t, err)
+		assert.Equal(t, DummyInstallerErrorMessage, err.Error())
+		assert.True(t, dummyState.Has(TestBinaryFullName), "binary should still be in state")
+	})
+}
+
+func TestUninstalledMessage(t *testing.T) {
+	t.Run("should report the uninstalled binary", func(t *testing.T) {
+		assert.Equal(t, "Uninstalled foo version v1.0.0", uninstalledMessage("foo version v1.0.0"))
+	})
+
+	t.Run("should report what would be uninstalled in dry run", func(t *testing.T) {
+		installer.DryRun = true
+		defer func() { installer.DryRun = false }()
+
+		assert.Equal(t, "Would uninstall foo", uninstalledMessage("foo"))
+	})
+}
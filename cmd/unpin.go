@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	UnpinUseMessage   = "unpin"
+	UnpinShortMessage = "unpin binaries so update manages them again"
+
+	UnpinArgsCountErrorMessage = "unpin need at least one argument, see above usage"
+)
+
+type UnpinCommandConfig struct {
+	azaState state.State
+}
+
+func newUnpinCommand(azaState state.State) *cobra.Command {
+	cfg := UnpinCommandConfig{
+		azaState: azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   UnpinUseMessage,
+		Short: UnpinShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				_ = cmd.Help()
+				return errors.New(UnpinArgsCountErrorMessage)
+			}
+			return executeUnpinCommand(cfg, args...)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	return cmd
+}
+
+func executeUnpinCommand(cfg UnpinCommandConfig, args ...string) error {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	report := newFailureReport("unpins", len(args))
+	for _, binaryName := range args {
+		binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
+		if !ok {
+			report.add(binaryName, fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName))
+			continue
+		}
+		if !binaryInfo.Pinned {
+			fmt.Printf("Binary %s is not pinned\n", binaryInfo.DisplayName())
+			continue
+		}
+		binaryInfo.Pinned = false
+		cfg.azaState.UpdateEntrie(binaryInfo)
+		fmt.Printf("Unpinned %s\n", binaryInfo.DisplayName())
+	}
+
+	if err := cfg.azaState.Save(); err != nil {
+		return err
+	}
+	return report.err()
+}
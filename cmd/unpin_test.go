@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+)
+
+func TestNewUnpinCommand(t *testing.T) {
+	t.Run("should create a new unpin command", func(t *testing.T) {
+		cmd := newUnpinCommand(&DummyState{})
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, UnpinUseMessage, cmd.Use)
+		assert.Equal(t, UnpinShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+
+	t.Run("should return an error when no args provided", func(t *testing.T) {
+		cmd := newUnpinCommand(&DummyState{})
+
+		err := cmd.RunE(cmd, []string{})
+		require.Error(t, err)
+		assert.Equal(t, UnpinArgsCountErrorMessage, err.Error())
+	})
+}
+
+func TestExecuteUnpinCommand(t *testing.T) {
+	t.Run("should unpin binary", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.Pinned = true
+		dummyState.UpdateEntrie(info)
+
+		err := executeUnpinCommand(UnpinCommandConfig{azaState: dummyState}, TestBinaryName)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyState.saveCount)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.False(t, info.Pinned)
+	})
+
+	t.Run("should ignore binary not pinned", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+
+		err := executeUnpinCommand(UnpinCommandConfig{azaState: dummyState}, TestBinaryName)
+
+		require.NoError(t, err)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.False(t, info.Pinned)
+	})
+
+	t.Run("should handle binary not in state", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+
+		err := executeUnpinCommand(UnpinCommandConfig{azaState: dummyState}, "unknown")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
+	})
+
+	t.Run("should save the other binaries unpinned", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.Pinned = true
+		dummyState.UpdateEntrie(info)
+
+		err := executeUnpinCommand(UnpinCommandConfig{azaState: dummyState}, "unknown", TestBinaryName)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 2 unpins failed")
+		assert.Equal(t, 1, dummyState.saveCount)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.False(t, info.Pinned)
+	})
+
+	t.Run("should handle error on state", func(t *testing.T) {
+		err := executeUnpinCommand(UnpinCommandConfig{azaState: &DummyState{onError: true}}, TestBinaryName)
+
+		require.Error(t, err)
+		assert.Equal(t, DummyStateErrorMessage, err.Error())
+	})
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
@@ -16,11 +22,24 @@ import (
 const (
 	UpdateUseMessage   = "update"
 	UpdateShortMessage = "update installed binaries for current user"
+
+	DefaultUpdateJobs = 4
 )
 
 type UpdateCommandConfig struct {
-	azaInstaller installer.Installer
-	azaState     state.State
+	azaInstaller  installer.Installer
+	azaState      state.State
+	includePinned bool
+	jobs          int
+}
+
+// updateResult is the outcome of a single binary update, its output is buffered so that
+// concurrent updates are printed per binary
+type updateResult struct {
+	binaryInfo dto.BinaryInfo
+	updated    bool
+	output     string
+	err        error
 }
 
 func newUpdateCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
@@ -33,85 +52,158 @@ func newUpdateCommand(azaInstaller installer.Installer, azaState state.State) *c
 		Use:   UpdateUseMessage,
 		Short: UpdateShortMessage,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return executeUpdateCommand(cfg, args...)
+			return executeUpdateCommand(cmd.Context(), cfg, args...)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
+	cmd.Flags().BoolVar(&cfg.includePinned, "include-pinned", false, "also update pinned binaries")
+	cmd.Flags().IntVarP(&cfg.jobs, "jobs", "j", DefaultUpdateJobs, "number of binaries updated concurrently")
+
 	return cmd
 }
 
-func executeUpdateCommand(cfg UpdateCommandConfig, args ...string) error {
+func executeUpdateCommand(ctx context.Context, cfg UpdateCommandConfig, args ...string) error {
 	err := cfg.azaState.Load()
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
+	defer cfg.azaState.Unlock()
 
+	binaries := make([]dto.BinaryInfo, 0, len(args))
+	report := newFailureReport("updates", len(args))
 	if len(args) > 0 {
 		for _, binaryName := range args {
 			binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
 			if !ok {
-				return fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName)
-			}
-			if err := checkUpdate(binaryInfo, cfg); err != nil {
-				return err
+				report.add(binaryName, fmt.Errorf("binary %s is not installed (or not managed by azabox)", binaryName))
+				continue
 			}
+			binaries = append(binaries, binaryInfo)
 		}
 	} else {
-		for _, binaryInfo := range cfg.azaState.Entries() {
-			if err := checkUpdate(binaryInfo, cfg); err != nil {
-				return err
-			}
+		entries := cfg.azaState.Entries()
+		for _, name := range slices.Sorted(maps.Keys(entries)) {
+			binaries = append(binaries, entries[name])
 		}
+		report.total = len(binaries)
 	}
 
-	return cfg.azaState.Save()
+	// the state is only written from here, results come back one binary at a time
+	for result := range runUpdates(ctx, cfg, binaries) {
+		fmt.Print(result.output)
+		if result.err != nil {
+			report.add(result.binaryInfo.DisplayName(), result.err)
+			continue
+		}
+		if result.updated {
+			cfg.azaState.UpdateEntrie(result.binaryInfo)
+		}
+	}
+
+	if err := cfg.azaState.Save(); err != nil {
+		return err
+	}
+	return report.err()
 }
 
-func checkUpdate(binaryInfo dto.BinaryInfo, cfg UpdateCommandConfig) error {
-	version, lresolver, err := resolveLatestVersion(binaryInfo)
+// runUpdates checks the binaries with at most cfg.jobs updates running at once,
+// each result is sent as soon as its binary is done
+func runUpdates(ctx context.Context, cfg UpdateCommandConfig, binaries []dto.BinaryInfo) <-chan updateResult {
+	pending := make(chan dto.BinaryInfo)
+	results := make(chan updateResult)
+
+	var wg sync.WaitGroup
+	for range min(max(cfg.jobs, 1), max(len(binaries), 1)) {
+		wg.Go(func() {
+			for binaryInfo := range pending {
+				results <- checkUpdate(ctx, binaryInfo, cfg)
+			}
+		})
+	}
+
+	go func() {
+		for _, binaryInfo := range binaries {
+			pending <- binaryInfo
+		}
+		close(pending)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func checkUpdate(ctx context.Context, binaryInfo dto.BinaryInfo, cfg UpdateCommandConfig) updateResult {
+	var out strings.Builder
+	result := updateResult{binaryInfo: binaryInfo}
+	// the remaining binaries are reported as cancelled without calling the resolvers
+	if result.err = ctx.Err(); result.err != nil {
+		return result
+	}
+
+	if binaryInfo.Pinned && !cfg.includePinned {
+		logging.Logger().Debug("binary pinned", "name", binaryInfo.DisplayName(), "version", binaryInfo.ActiveVersion)
+		fmt.Fprintf(&out, "Binary %s is pinned to %s, held back\n", binaryInfo.DisplayName(), binaryInfo.ActiveVersion)
+		result.output = out.String()
+		return result
+	}
+
+	version, lresolver, err := resolveLatestVersion(ctx, binaryInfo)
 	if err != nil {
-		return err
+		result.err = err
+		return result
 	}
 
 	logging.Logger().Debug("update command", "resolvedVersion", version,
 		"name", binaryInfo.DisplayName(), "currentVersion", binaryInfo.InstalledVersion)
-	if version != binaryInfo.InstalledVersion {
+	// a GitHub release is tracked by its name while a constraint resolves to its tag
+	if !sameVersion(version, binaryInfo.InstalledVersion) {
 		logging.Logger().Debug("update binary", "name", binaryInfo.DisplayName(),
 			"currentVersion", binaryInfo.InstalledVersion, "newVersion", version)
-		fmt.Printf("Updating %s from %s to %s\n", binaryInfo.DisplayName(),
+		fmt.Fprintf(&out, "Updating %s from %s to %s\n", binaryInfo.DisplayName(),
 			binaryInfo.InstalledVersion, version)
 
-		return update(lresolver, &binaryInfo, cfg)
+		// the download progress cannot wait for the summary, it goes straight to the terminal
+		progressOut := installer.WithProgress(&out, os.Stderr, binaryInfo.DisplayName())
+		result.err = update(ctx, progressOut, lresolver, &result.binaryInfo, cfg)
+		result.updated = result.err == nil
 	} else {
-		fmt.Printf("Binary %s is up to date\n", binaryInfo.DisplayName())
+		fmt.Fprintf(&out, "Binary %s is up to date\n", binaryInfo.DisplayName())
 	}
-	return nil
+	result.output = out.String()
+	return result
 }
 
-func resolveLatestVersion(binaryInfo dto.BinaryInfo) (string, resolver.Resolver, error) {
-	resolvers := resolver.GetRegistryResolver().GetResolvers()
-	for lresolver := range resolvers {
-		if lresolver.Name() == binaryInfo.Resolver {
-			version, err := lresolver.ResolveLatestVersion(binaryInfo)
-			return version, lresolver, err
-		}
+func findResolver(name string) (resolver.Resolver, error) {
+	lresolver, ok := resolver.GetRegistryResolver().Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown resolver %s", name)
 	}
-	return "", nil, fmt.Errorf("unknown resolver %s", binaryInfo.Resolver)
+	return lresolver, nil
 }
 
-func update(lresolver resolver.Resolver, binaryInfo *dto.BinaryInfo, cfg UpdateCommandConfig) error {
-	binaryInfo.Version = resolver.LatestVersion
-	resolvedUrl, err := lresolver.Resolve(binaryInfo)
+func resolveLatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, resolver.Resolver, error) {
+	lresolver, err := findResolver(binaryInfo.Resolver)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
+	version, err := resolver.LatestMatchingVersion(ctx, lresolver, binaryInfo)
+	return version, lresolver, err
+}
 
-	if err = cfg.azaInstaller.Install(binaryInfo, resolvedUrl); err != nil {
+func update(ctx context.Context, out io.Writer, lresolver resolver.Resolver, binaryInfo *dto.BinaryInfo,
+	cfg UpdateCommandConfig,
+) error {
+	// a binary with a constraint keeps it as requested version
+	if binaryInfo.Constraint == "" {
+		binaryInfo.Version = resolver.LatestVersion
+	}
+	resolvedUrl, err := resolver.ResolveMatching(ctx, lresolver, binaryInfo)
+	if err != nil {
 		return err
 	}
 
-	cfg.azaState.UpdateEntrie(*binaryInfo)
-	return nil
+	return cfg.azaInstaller.Install(ctx, out, binaryInfo, resolvedUrl)
 }
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
 	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
 )
 
@@ -22,7 +27,7 @@ func TestResolveLatestVersion(t *testing.T) {
 			Resolver: DummyResolverName,
 		}
 
-		version, lresolver, err := resolveLatestVersion(binaryInfo)
+		version, lresolver, err := resolveLatestVersion(t.Context(), binaryInfo)
 
 		require.NoError(t, err)
 		require.NotNil(t, lresolver)
@@ -42,7 +47,7 @@ func TestResolveLatestVersion(t *testing.T) {
 			Resolver: resolverName,
 		}
 
-		version, lresolver, err := resolveLatestVersion(binaryInfo)
+		version, lresolver, err := resolveLatestVersion(t.Context(), binaryInfo)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), resolverName)
@@ -73,16 +78,45 @@ func TestUpdate(t *testing.T) {
 			azaState:     &dummyState,
 		}
 
-		err := update(&dummyResolver, &binaryInfo, cfg)
+		err := update(t.Context(), io.Discard, &dummyResolver, &binaryInfo, cfg)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, dummyResolver.resolveCount, "resolve method should have been called once")
 		assert.Equal(t, 1, dummyInstaller.installCount, "install method should have been called once")
-		assert.Len(t, cfg.azaState.Entries(), 1)
+		assert.Equal(t, TestBinaryVersion, binaryInfo.InstalledVersion)
+	})
 
-		info, ok := cfg.azaState.Entry(TestBinaryName)
-		assert.True(t, ok)
-		assert.Equal(t, TestBinaryVersion, info.InstalledVersion)
+	t.Run("should stay inside the constraint", func(t *testing.T) {
+		dummyState := DummyState{
+			binaries: make(map[string]dto.BinaryInfo, 1),
+		}
+		binaryInfo := dto.BinaryInfo{
+			FullName:         TestBinaryName,
+			Owner:            TestBinaryName,
+			Name:             TestBinaryName,
+			Version:          "~1.2",
+			Constraint:       "~1.2",
+			InstalledVersion: "v1.2.0",
+			Resolver:         DummyResolverName,
+		}
+		dummyState.UpdateEntrie(binaryInfo)
+		dummyResolver := DummyResolver{versions: []string{"v1.2.0", "v1.2.3", "v1.3.0", "v2.0.0"}}
+		cfg := UpdateCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     &dummyState,
+		}
+
+		version, err := resolver.LatestMatchingVersion(t.Context(), &dummyResolver, binaryInfo)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.2.3", version)
+		assert.Equal(t, 0, dummyResolver.resolveLatestVersionCount, "latest version should not be used")
+
+		err = update(t.Context(), io.Discard, &dummyResolver, &binaryInfo, cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1.2.3"}, dummyResolver.resolvedVersions)
+		assert.Equal(t, "~1.2", binaryInfo.Constraint, "constraint should be kept")
+		assert.Equal(t, "~1.2", binaryInfo.Version)
 	})
 
 	t.Run("should handle error", func(t *testing.T) {
@@ -130,7 +164,7 @@ func TestUpdate(t *testing.T) {
 					azaState:     &dummyState,
 				}
 
-				err := update(&dummyResolver, &binaryInfo, cfg)
+				err := update(t.Context(), io.Discard, &dummyResolver, &binaryInfo, cfg)
 
 				require.Error(t, err)
 				assert.Equal(t, tc.installCount, dummyInstaller.installCount)
@@ -197,10 +231,11 @@ func TestCheckUpdate(t *testing.T) {
 					azaInstaller: &dummyInstaller,
 					azaState:     &dummyState,
 				}
-				resolver.GetRegistryResolver().GetResolvers().Clear()
+				resolver.GetRegistryResolver().Clear()
 				resolver.GetRegistryResolver().Register(&dummyResolver)
 
-				err := checkUpdate(binaryInfo, cfg)
+				result := checkUpdate(t.Context(), binaryInfo, cfg)
+				err := result.err
 				resolver.GetRegistryResolver().Unregister(&dummyResolver)
 
 				switch {
@@ -211,15 +246,96 @@ func TestCheckUpdate(t *testing.T) {
 					require.Error(t, err)
 					assert.Equal(t, DummyInstallerErrorMessage, err.Error())
 				default:
-					info, ok := cfg.azaState.Entry(TestBinaryName)
 					require.NoError(t, err)
-					assert.True(t, ok, "binary should be in state")
-					assert.Equal(t, TestBinaryVersion, info.InstalledVersion)
+					assert.Equal(t, tc.installCount == 1, result.updated)
+					assert.Equal(t, TestBinaryVersion, result.binaryInfo.InstalledVersion)
 				}
 				assert.Equal(t, tc.installCount, dummyInstaller.installCount)
 			})
 		}
 	})
+
+	t.Run("should not update a constrained release tracked by its name", func(t *testing.T) {
+		binaryInfo := newTestBinary("Helm v3.15.0")
+		binaryInfo.Constraint = "~3.15"
+		dummyResolver := &DummyResolver{versions: []string{"v3.14.0", "v3.15.0", "v4.0.0"}}
+		dummyInstaller := &DummyInstaller{}
+		cfg := UpdateCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     createFakeState([]dto.BinaryInfo{binaryInfo}),
+		}
+		resolver.GetRegistryResolver().Clear()
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		result := checkUpdate(t.Context(), binaryInfo, cfg)
+
+		require.NoError(t, result.err)
+		assert.False(t, result.updated)
+		assert.Contains(t, result.output, "is up to date")
+		assert.Equal(t, 0, dummyInstaller.installCount)
+	})
+}
+
+func TestCheckUpdatePinned(t *testing.T) {
+	newPinnedBinary := func() dto.BinaryInfo {
+		binaryInfo := newTestBinary(FakeVersionToUpdate)
+		binaryInfo.Pinned = true
+		return binaryInfo
+	}
+
+	t.Run("should hold back pinned binary", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState([]dto.BinaryInfo{newPinnedBinary()})
+		dummyResolver := &DummyResolver{}
+		dummyInstaller := &DummyInstaller{}
+		cfg := UpdateCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		resolver.GetRegistryResolver().Clear()
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		result := checkUpdate(t.Context(), newPinnedBinary(), cfg)
+
+		require.NoError(t, result.err)
+		assert.False(t, result.updated)
+		assert.Contains(t, result.output, "is pinned to X.Y.Z, held back")
+		assert.Equal(t, 0, dummyResolver.resolveLatestVersionCount, "pinned binary should not be resolved")
+		assert.Equal(t, 0, dummyInstaller.installCount)
+	})
+
+	t.Run("should update pinned binary when included", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newPinnedBinary()})
+		dummyResolver := &DummyResolver{}
+		dummyInstaller := &DummyInstaller{}
+		cfg := UpdateCommandConfig{
+			azaInstaller:  dummyInstaller,
+			azaState:      dummyState,
+			includePinned: true,
+		}
+		resolver.GetRegistryResolver().Clear()
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		result := checkUpdate(t.Context(), newPinnedBinary(), cfg)
+
+		require.NoError(t, result.err)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		info := result.binaryInfo
+		assert.Equal(t, TestBinaryVersion, info.InstalledVersion)
+		assert.True(t, info.Pinned, "binary should stay pinned")
+	})
+
+	t.Run("should bind include pinned flag", func(t *testing.T) {
+		cmd := newUpdateCommand(&DummyInstaller{}, &DummyState{})
+
+		flag := cmd.Flags().Lookup("include-pinned")
+
+		require.NotNil(t, flag)
+		assert.Equal(t, "false", flag.DefValue)
+	})
 }
 
 func TestExecuteUpdateCommand(t *testing.T) {
@@ -249,10 +365,10 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 			azaState:     dummyState,
 		}
-		resolver.GetRegistryResolver().GetResolvers().Clear()
+		resolver.GetRegistryResolver().Clear()
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
-		err := executeUpdateCommand(cfg, binaryName1, binaryName2)
+		err := executeUpdateCommand(t.Context(), cfg, binaryName1, binaryName2)
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		assert.NoError(t, err)
@@ -272,7 +388,7 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 		}
 
-		err := executeUpdateCommand(cfg, "")
+		err := executeUpdateCommand(t.Context(), cfg, "")
 		require.Error(t, err)
 		assert.Equal(t, DummyStateErrorMessage, err.Error())
 		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
@@ -288,10 +404,10 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 		}
 
-		err := executeUpdateCommand(cfg, "unknown")
+		err := executeUpdateCommand(t.Context(), cfg, "unknown")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
-		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+		assert.Equal(t, 1, dummyState.saveCount, "state should be saved for the other binaries")
 	})
 
 	t.Run("should handle error in the update process", func(t *testing.T) {
@@ -313,14 +429,14 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 		}
 
-		resolver.GetRegistryResolver().GetResolvers().Clear()
+		resolver.GetRegistryResolver().Clear()
 		resolver.GetRegistryResolver().Register(dummyResolver)
 		dummyState.UpdateEntrie(binaryInfo)
 
-		err := executeUpdateCommand(cfg, TestBinaryName)
+		err := executeUpdateCommand(t.Context(), cfg, TestBinaryName)
 		require.Error(t, err)
 		assert.Equal(t, DummyResolverErrorMessage, err.Error())
-		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+		assert.Equal(t, 1, dummyState.saveCount, "state should be saved for the other binaries")
 	})
 
 	t.Run("should update all binaries when no parameter are given", func(t *testing.T) {
@@ -346,10 +462,10 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 			azaState:     dummyState,
 		}
-		resolver.GetRegistryResolver().GetResolvers().Clear()
+		resolver.GetRegistryResolver().Clear()
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
-		err := executeUpdateCommand(cfg, []string{}...)
+		err := executeUpdateCommand(t.Context(), cfg, []string{}...)
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		assert.NoError(t, err)
@@ -378,15 +494,112 @@ func TestExecuteUpdateCommand(t *testing.T) {
 			azaInstaller: dummyInstaller,
 			azaState:     dummyState,
 		}
-		resolver.GetRegistryResolver().GetResolvers().Clear()
+		resolver.GetRegistryResolver().Clear()
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
-		err := executeUpdateCommand(cfg, []string{}...)
+		err := executeUpdateCommand(t.Context(), cfg, []string{}...)
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		assert.Error(t, err)
-		assert.Equal(t, DummyInstallerErrorMessage, err.Error())
-		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+		assert.Contains(t, err.Error(), DummyInstallerErrorMessage)
+		assert.Equal(t, 1, dummyState.saveCount, "state should be saved for the other binaries")
+	})
+}
+
+// concurrentInstaller records how many installs run at the same time
+type concurrentInstaller struct {
+	DummyInstaller
+
+	running    atomic.Int32
+	maxRunning atomic.Int32
+}
+
+func (i *concurrentInstaller) Install(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo,
+	url string,
+) error {
+	running := i.running.Add(1)
+	defer i.running.Add(-1)
+	for {
+		current := i.maxRunning.Load()
+		if running <= current || i.maxRunning.CompareAndSwap(current, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return i.DummyInstaller.Install(ctx, out, binaryInfo, url)
+}
+
+func TestParallelUpdate(t *testing.T) {
+	newBinaries := func(count int) []dto.BinaryInfo {
+		binaries := make([]dto.BinaryInfo, 0, count)
+		for i := range count {
+			name := fmt.Sprintf("%s%d", TestBinaryName, i)
+			binaries = append(binaries, dto.BinaryInfo{
+				FullName: name + "/" + name, Name: name, Owner: name,
+				InstalledVersion: FakeVersionToUpdate, Resolver: DummyResolverName,
+			})
+		}
+		return binaries
+	}
+
+	t.Run("should bound concurrent updates to jobs", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState(newBinaries(6))
+		dummyResolver := &DummyResolver{}
+		dummyInstaller := &concurrentInstaller{}
+		cfg := UpdateCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+			jobs:         2,
+		}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		err := executeUpdateCommand(t.Context(), cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, 6, dummyInstaller.installCount)
+		assert.Equal(t, int32(2), dummyInstaller.maxRunning.Load())
+		for _, binaryInfo := range dummyState.Entries() {
+			assert.Equal(t, TestBinaryVersion, binaryInfo.InstalledVersion)
+		}
+	})
+
+	t.Run("should keep updating when a binary fails", func(t *testing.T) {
+		binaries := newBinaries(3)
+		binaries[1].Resolver = "other"
+		dummyState := createFakeState(binaries)
+		dummyResolver := &DummyResolver{}
+		dummyInstaller := &DummyInstaller{}
+		cfg := UpdateCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+			jobs:         DefaultUpdateJobs,
+		}
+		resolver.GetRegistryResolver().Register(dummyResolver)
+		defer resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		err := executeUpdateCommand(t.Context(), cfg)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, PartialFailureExitCode, exitErr.Code)
+		assert.Equal(t, "1 of 3 updates failed:\n  - foo1: unknown resolver other", err.Error())
+		assert.Equal(t, 2, dummyInstaller.installCount)
+		assert.Equal(t, 1, dummyState.saveCount)
+		info, _ := dummyState.Entry("foo0/foo0")
+		assert.Equal(t, TestBinaryVersion, info.InstalledVersion)
+		info, _ = dummyState.Entry("foo1/foo1")
+		assert.Equal(t, FakeVersionToUpdate, info.InstalledVersion, "failed binary should be left as is")
+	})
+
+	t.Run("should bind jobs flag", func(t *testing.T) {
+		cmd := newUpdateCommand(&DummyInstaller{}, &DummyState{})
+
+		flag := cmd.Flags().Lookup("jobs")
+
+		require.NotNil(t, flag)
+		assert.Equal(t, fmt.Sprint(DefaultUpdateJobs), flag.DefValue)
 	})
 }
 
@@ -408,14 +621,13 @@ func TestNewUpdateCommand(t *testing.T) {
 
 		dummyResolver := &DummyResolver{onError: true}
 		dummyInstaller := &DummyInstaller{}
-		resolver.GetRegistryResolver().GetResolvers().Clear()
+		resolver.GetRegistryResolver().Clear()
 		resolver.GetRegistryResolver().Register(dummyResolver)
 
 		err := newUpdateCommand(dummyInstaller, dummyState).Execute()
 		resolver.GetRegistryResolver().Unregister(dummyResolver)
 
 		assert.Error(t, err)
-		assert.Equal(t, DummyResolverErrorMessage, err.Error())
-		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+		assert.Contains(t, err.Error(), DummyResolverErrorMessage)
 	})
 }
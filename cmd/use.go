@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/state"
+)
+
+const (
+	UseUseMessage   = "use"
+	UseShortMessage = "switch the active version of an installed binary"
+
+	UseArgsCountErrorMessage = "use need a binary and a version, see above usage"
+)
+
+type UseCommandConfig struct {
+	azaInstaller installer.Installer
+	azaState     state.State
+}
+
+func newUseCommand(azaInstaller installer.Installer, azaState state.State) *cobra.Command {
+	cfg := UseCommandConfig{
+		azaInstaller: azaInstaller,
+		azaState:     azaState,
+	}
+
+	cmd := &cobra.Command{
+		Use:   UseUseMessage,
+		Short: UseShortMessage,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				_ = cmd.Help()
+				return errors.New(UseArgsCountErrorMessage)
+			}
+			return executeUseCommand(cmd.Context(), cfg, args[0], args[1])
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	return cmd
+}
+
+func executeUseCommand(ctx context.Context, cfg UseCommandConfig, binaryName, version string) error {
+	err := cfg.azaState.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer cfg.azaState.Unlock()
+
+	binaryInfo, ok := cfg.azaState.Entry(dto.NormalizeName(binaryName))
+	if !ok {
+		return fmt.Errorf("binary %s is not installed (or not managed by azabox), use install command first",
+			binaryName)
+	}
+
+	if err := switchVersion(ctx, &binaryInfo, version, cfg); err != nil {
+		return err
+	}
+
+	cfg.azaState.UpdateEntrie(binaryInfo)
+	return cfg.azaState.Save()
+}
+
+func switchVersion(ctx context.Context, binaryInfo *dto.BinaryInfo, version string, cfg UseCommandConfig) error {
+	version = trackedVersion(*binaryInfo, version)
+	logging.Logger().Debug("switch version", "name", binaryInfo.DisplayName(),
+		"activeVersion", binaryInfo.ActiveVersion, "version", version)
+
+	if version == binaryInfo.ActiveVersion {
+		fmt.Printf("Binary %s already uses version %s\n", binaryInfo.DisplayName(), version)
+		return nil
+	}
+
+	_, tracked := binaryInfo.FindVersion(version)
+	if !tracked || !cfg.azaInstaller.IsInstalled(binaryInfo, version) {
+		installedVersion, err := installVersion(ctx, os.Stdout, binaryInfo, version, cfg.azaInstaller)
+		if err != nil {
+			return err
+		}
+		version = installedVersion
+	}
+
+	if err := cfg.azaInstaller.Link(binaryInfo, version); err != nil {
+		return err
+	}
+	binaryInfo.ActiveVersion = version
+	// a dry run link already reported the switch
+	if !installer.DryRun {
+		fmt.Printf("Switched %s to version %s\n", binaryInfo.DisplayName(), version)
+	}
+	return nil
+}
+
+// installVersion downloads a given version of an already known binary and tracks it without touching
+// its latest installed version nor its link, it returns the version as named by the resolver
+func installVersion(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, version string,
+	azaInstaller installer.Installer,
+) (string, error) {
+	lresolver, err := findResolver(binaryInfo.Resolver)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(out, "Version %s of %s is not installed, installing it\n", version, binaryInfo.DisplayName())
+	resolvedInfo := *binaryInfo
+	resolvedInfo.Version = version
+	resolvedInfo.Versions = nil
+	resolvedUrl, err := lresolver.Resolve(ctx, &resolvedInfo)
+	if err != nil {
+		return "", err
+	}
+	if resolvedUrl == "" {
+		return "", fmt.Errorf("binary \"%s\" with version \"%s\" not found", binaryInfo.FullName, version)
+	}
+
+	if err := azaInstaller.InstallVersion(ctx, out, &resolvedInfo, resolvedUrl); err != nil {
+		return "", err
+	}
+	for _, versionInfo := range resolvedInfo.Versions {
+		binaryInfo.AddVersion(versionInfo)
+	}
+	return resolvedInfo.InstalledVersion, nil
+}
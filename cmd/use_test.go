@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/resolver"
+)
+
+func TestNewUseCommand(t *testing.T) {
+	t.Run("should create a new use command", func(t *testing.T) {
+		cmd := newUseCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+
+		require.NotNil(t, cmd)
+		assert.Equal(t, UseUseMessage, cmd.Use)
+		assert.Equal(t, UseShortMessage, cmd.Short)
+		assert.NotNil(t, cmd.RunE)
+		assert.True(t, cmd.SilenceErrors)
+		assert.True(t, cmd.SilenceUsage)
+	})
+
+	t.Run("should return an error when args count is wrong", func(t *testing.T) {
+		cmd := newUseCommand(&DummyInstaller{}, &DummyState{})
+		cmd.SetContext(t.Context())
+
+		err := cmd.RunE(cmd, []string{TestBinaryName})
+		require.Error(t, err)
+		assert.Equal(t, UseArgsCountErrorMessage, err.Error())
+	})
+}
+
+func TestExecuteUseCommand(t *testing.T) {
+	t.Run("should switch to an installed version", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.AddVersion(dto.VersionInfo{Version: TestBinaryVersion})
+		dummyState.UpdateEntrie(info)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{TestBinaryVersion}}
+		cfg := UseCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUseCommand(t.Context(), cfg, TestBinaryName, TestBinaryVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, dummyInstaller.installCount, "should not install an installed version")
+		assert.Equal(t, 1, dummyInstaller.linkCount)
+		assert.Equal(t, 1, dummyState.saveCount)
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, TestBinaryVersion, info.ActiveVersion)
+		assert.Equal(t, FakeVersionToUpdate, info.InstalledVersion, "latest installed version should not change")
+	})
+
+	t.Run("should install missing version before switching", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		dummyInstaller := &DummyInstaller{}
+		dummyResolver := &DummyResolver{}
+		cfg := UseCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		resolver.GetRegistryResolver().Clear()
+		resolver.GetRegistryResolver().Register(dummyResolver)
+
+		err := executeUseCommand(t.Context(), cfg, TestBinaryName, TestBinaryVersion)
+		resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, dummyResolver.resolveCount)
+		assert.Equal(t, 1, dummyInstaller.installCount)
+		assert.Equal(t, 1, dummyInstaller.linkCount)
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, TestBinaryVersion, info.ActiveVersion)
+		assert.Equal(t, FakeVersionToUpdate, info.InstalledVersion, "latest installed version should not change")
+		assert.Len(t, info.Versions, 2, "both versions should be tracked")
+	})
+
+	t.Run("should not relink the active version", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		dummyInstaller := &DummyInstaller{}
+		cfg := UseCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUseCommand(t.Context(), cfg, TestBinaryName, FakeVersionToUpdate)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, dummyInstaller.linkCount)
+	})
+
+	t.Run("should match a tracked version named differently", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary("Helm v3.15.0")})
+		info, _ := dummyState.Entry(TestBinaryFullName)
+		info.AddVersion(dto.VersionInfo{Version: "3.14.0"})
+		dummyState.UpdateEntrie(info)
+		dummyInstaller := &DummyInstaller{installedVersions: []string{"3.14.0"}}
+		cfg := UseCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+
+		err := executeUseCommand(t.Context(), cfg, TestBinaryName, "v3.14.0")
+		require.NoError(t, err)
+		err = executeUseCommand(t.Context(), cfg, TestBinaryName, "v3.14.0")
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, dummyInstaller.installCount, "should not install a tracked version again")
+		assert.Equal(t, 1, dummyInstaller.linkCount, "the active version should not be relinked")
+		info, _ = dummyState.Entry(TestBinaryFullName)
+		assert.Equal(t, "3.14.0", info.ActiveVersion)
+		assert.Len(t, info.Versions, 2, "no duplicate version should be tracked")
+	})
+
+	t.Run("should handle binary not in state", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		cfg := UseCommandConfig{
+			azaInstaller: &DummyInstaller{},
+			azaState:     dummyState,
+		}
+
+		err := executeUseCommand(t.Context(), cfg, "unknown", TestBinaryVersion)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed (or not managed by azabox)")
+		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+	})
+
+	t.Run("should handle error in resolver", func(t *testing.T) {
+		dummyState := createFakeState([]dto.BinaryInfo{newTestBinary(FakeVersionToUpdate)})
+		dummyInstaller := &DummyInstaller{}
+		dummyResolver := &DummyResolver{onError: true}
+		cfg := UseCommandConfig{
+			azaInstaller: dummyInstaller,
+			azaState:     dummyState,
+		}
+		resolver.GetRegistryResolver().Clear()
+		resolver.GetRegistryResolver().Register(dummyResolver)
+
+		err := executeUseCommand(t.Context(), cfg, TestBinaryName, TestBinaryVersion)
+		resolver.GetRegistryResolver().Unregister(dummyResolver)
+
+		require.Error(t, err)
+		assert.Equal(t, DummyResolverErrorMessage, err.Error())
+		assert.Equal(t, 0, dummyInstaller.linkCount)
+		assert.Equal(t, 0, dummyState.saveCount, "state save method should not be called")
+	})
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConfigFileName = "config.yaml"
+	// DefaultCacheMaxSize bounds the cached downloads when no size is configured
+	DefaultCacheMaxSize = 1 << 30
+)
+
+// SignaturePolicy pins the keys used to verify the signatures published for a tool
+type SignaturePolicy struct {
+	// Required makes the install fail when no signature could be verified
+	Required bool `yaml:"required"`
+	// CosignKey is the path of a PEM encoded cosign public key
+	CosignKey string `yaml:"cosign-key"`
+	// MinisignKey is a minisign public key, either inline or the path of a .pub file
+	MinisignKey string `yaml:"minisign-key"`
+	// GPGKeyring is the path of a keyring used with gpgv
+	GPGKeyring string `yaml:"gpg-keyring"`
+}
+
+type ToolConfig struct {
+	Signature SignaturePolicy `yaml:"signature"`
+}
+
+// GitHubConfig configures the access to the GitHub API
+type GitHubConfig struct {
+	// Token authenticates the requests, GITHUB_TOKEN and GH_TOKEN take precedence
+	Token string `yaml:"token"`
+}
+
+// CacheConfig configures the download cache
+type CacheConfig struct {
+	// MaxSize bounds the cached downloads, e.g. 512M or 2GiB
+	MaxSize string `yaml:"max-size"`
+}
+
+type Config struct {
+	GitHub GitHubConfig `yaml:"github"`
+	Cache  CacheConfig  `yaml:"cache"`
+	// Shims links the binaries to azabox which runs the version selected for the current directory
+	Shims bool                  `yaml:"shims"`
+	Tools map[string]ToolConfig `yaml:"tools"`
+}
+
+// Load reads the configuration file, a missing file is an empty configuration
+func Load(path string) (Config, error) {
+	cfg := Config{Tools: make(map[string]ToolConfig)}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if _, err := cfg.Cache.MaxSizeBytes(); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: cache max-size: %w", path, err)
+	}
+
+	// tools can be configured by their short name like on the command line
+	tools := make(map[string]ToolConfig, len(cfg.Tools))
+	for name, tool := range cfg.Tools {
+		tool.Signature.CosignKey = expandHome(tool.Signature.CosignKey)
+		tool.Signature.MinisignKey = expandHome(tool.Signature.MinisignKey)
+		tool.Signature.GPGKeyring = expandHome(tool.Signature.GPGKeyring)
+		tools[dto.NormalizeName(name)] = tool
+	}
+	cfg.Tools = tools
+	return cfg, nil
+}
+
+// MaxSizeBytes returns the maximum size of the download cache, the default one when not configured
+func (c CacheConfig) MaxSizeBytes() (int64, error) {
+	if c.MaxSize == "" {
+		return DefaultCacheMaxSize, nil
+	}
+	return ParseSize(c.MaxSize)
+}
+
+func (c Config) SignaturePolicy(fullName string) SignaturePolicy {
+	return c.Tools[fullName].Signature
+}
+
+// HasKey reports whether at least one key is pinned to verify signatures
+func (p SignaturePolicy) HasKey() bool {
+	return p.CosignKey != "" || p.MinisignKey != "" || p.GPGKeyring != ""
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[2:])
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("should load signature policies", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		content := `tools:
+  helmfile:
+    signature:
+      required: true
+      cosign-key: /keys/cosign.pub
+  jedisct1/minisign:
+    signature:
+      minisign-key: RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3
+      gpg-keyring: ~/keys/minisign.gpg
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		homeDir, err := os.UserHomeDir()
+		require.NoError(t, err)
+
+		cfg, err := Load(path)
+
+		require.NoError(t, err)
+		policy := cfg.SignaturePolicy("helmfile/helmfile")
+		assert.True(t, policy.Required, "short name should be normalized")
+		assert.Equal(t, "/keys/cosign.pub", policy.CosignKey)
+		assert.True(t, policy.HasKey())
+		policy = cfg.SignaturePolicy("jedisct1/minisign")
+		assert.False(t, policy.Required)
+		assert.Equal(t, "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3", policy.MinisignKey)
+		assert.Equal(t, filepath.Join(homeDir, "keys", "minisign.gpg"), policy.GPGKeyring)
+	})
+
+	t.Run("should load github token", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte("github:\n  token: ghp_secret\n"), 0o600))
+
+		cfg, err := Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ghp_secret", cfg.GitHub.Token)
+	})
+
+	t.Run("should load cache max size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte("cache:\n  max-size: 512M\n"), 0o600))
+
+		cfg, err := Load(path)
+
+		require.NoError(t, err)
+		size, err := cfg.Cache.MaxSizeBytes()
+		require.NoError(t, err)
+		assert.Equal(t, int64(512<<20), size)
+	})
+
+	t.Run("should handle invalid cache max size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte("cache:\n  max-size: lots\n"), 0o600))
+
+		_, err := Load(path)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cache max-size")
+	})
+
+	t.Run("should handle missing file", func(t *testing.T) {
+		cfg, err := Load(filepath.Join(t.TempDir(), ConfigFileName))
+
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Tools)
+		assert.False(t, cfg.SignaturePolicy("foo/foo").HasKey())
+	})
+
+	t.Run("should handle invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte("tools: [foo"), 0o600))
+
+		_, err := Load(path)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid config file")
+	})
+}
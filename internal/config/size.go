@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are matched in order, longer suffixes first
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{suffix: "GIB", multiplier: 1 << 30},
+	{suffix: "MIB", multiplier: 1 << 20},
+	{suffix: "KIB", multiplier: 1 << 10},
+	{suffix: "GB", multiplier: 1 << 30},
+	{suffix: "MB", multiplier: 1 << 20},
+	{suffix: "KB", multiplier: 1 << 10},
+	{suffix: "G", multiplier: 1 << 30},
+	{suffix: "M", multiplier: 1 << 20},
+	{suffix: "K", multiplier: 1 << 10},
+	{suffix: "B", multiplier: 1},
+}
+
+// ParseSize reads a size like 512M or 2GiB, units are powers of 1024 and a plain number is in bytes
+func ParseSize(size string) (int64, error) {
+	number, multiplier := strings.TrimSpace(size), int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(strings.ToUpper(number), unit.suffix) {
+			number = strings.TrimSpace(number[:len(number)-len(unit.suffix)])
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return value * multiplier, nil
+}
+
+// FormatSize writes a size with the largest unit keeping at least one unit
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 2 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", value, "KMG"[exp])
+}
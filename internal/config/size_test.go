@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		size     string
+		expected int64
+	}{
+		{size: "42", expected: 42},
+		{size: "42B", expected: 42},
+		{size: "512K", expected: 512 << 10},
+		{size: "512M", expected: 512 << 20},
+		{size: "512mb", expected: 512 << 20},
+		{size: "2GiB", expected: 2 << 30},
+		{size: "2 G", expected: 2 << 30},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.size, func(t *testing.T) {
+			size, err := ParseSize(tc.size)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+
+	for _, invalid := range []string{"", "G", "-1M", "1.5G", "12TB"} {
+		t.Run("should reject "+invalid, func(t *testing.T) {
+			_, err := ParseSize(invalid)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512B", FormatSize(512))
+	assert.Equal(t, "1.5KiB", FormatSize(1536))
+	assert.Equal(t, "512.0MiB", FormatSize(512<<20))
+	assert.Equal(t, "2.0GiB", FormatSize(2<<30))
+	assert.Equal(t, "2048.0GiB", FormatSize(2<<40))
+}
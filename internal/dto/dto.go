@@ -2,16 +2,55 @@ package dto
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
+type VersionInfo struct {
+	Version     string
+	Path        string
+	InstalledAt time.Time
+	URL         string
+	// SHA256 is the digest of the downloaded asset, Verified tells if it matched a published checksum
+	SHA256   string
+	Verified bool
+	// Signature is the method the asset signature has been verified with, empty when not verified
+	Signature string
+	// ChecksumURL, SignatureURLs and AssetAPIURLs are the release files the asset was verified and downloaded
+	// with, they are locked so that a frozen install does not resolve the binary again
+	ChecksumURL   string            `json:",omitempty"`
+	SignatureURLs []string          `json:",omitempty"`
+	AssetAPIURLs  map[string]string `json:",omitempty"`
+}
+
 type BinaryInfo struct {
-	FullName         string
-	Name             string
-	Owner            string
-	Version          string
+	FullName string
+	Name     string
+	Owner    string
+	Version  string
+	// Constraint is the version range the binary is kept in (e.g. "~1.2"), empty to follow the latest release
+	Constraint string
+	// Pinned binaries are held back by update, they stay on their active version
+	Pinned           bool
 	InstalledVersion string
+	ActiveVersion    string
 	Resolver         string
+	// BaseURL is the instance the binary was resolved from, empty for the resolver default
+	BaseURL string
+	// URLTemplate, TemplateVars and LatestVersionURL describe binaries installed from an arbitrary url
+	URLTemplate      string
+	TemplateVars     map[string]string
+	LatestVersionURL string
+	Versions         []VersionInfo
+	// ChecksumURL is set by the resolver when the release publishes checksums, it is not saved in the state
+	ChecksumURL string `json:"-"`
+	// SignatureURLs are the detached signatures published for the asset or its checksum file, not saved either
+	SignatureURLs []string `json:"-"`
+	// AssetAPIURLs maps the release files above to the API endpoint serving them, not saved either
+	AssetAPIURLs map[string]string `json:"-"`
+	// LockedSHA256 is the digest recorded in a lockfile, the asset must match it, not saved either
+	LockedSHA256 string `json:"-"`
 }
 
 func (b BinaryInfo) String() string {
@@ -19,11 +58,23 @@ func (b BinaryInfo) String() string {
 		return ""
 	}
 
-	if b.Name == b.Owner {
-		return fmt.Sprintf("%s in version %s", b.Name, b.InstalledVersion)
-	} else {
-		return fmt.Sprintf("%s in version %s", b.FullName, b.InstalledVersion)
+	version := b.InstalledVersion
+	details := make([]string, 0, 3)
+	if b.ActiveVersion != "" && b.ActiveVersion != b.InstalledVersion {
+		version = b.ActiveVersion
+		details = append(details, "latest installed "+b.InstalledVersion)
+	}
+	if b.Constraint != "" {
+		details = append(details, "constraint "+b.Constraint)
+	}
+	if b.Pinned {
+		details = append(details, "pinned")
+	}
+
+	if len(details) == 0 {
+		return fmt.Sprintf("%s in version %s", b.DisplayName(), version)
 	}
+	return fmt.Sprintf("%s in version %s (%s)", b.DisplayName(), version, strings.Join(details, ", "))
 }
 
 func NormalizeName(name string) string {
@@ -39,3 +90,54 @@ func (b BinaryInfo) DisplayName() string {
 	}
 	return b.FullName
 }
+
+func (b BinaryInfo) FindVersion(version string) (VersionInfo, bool) {
+	idx := slices.IndexFunc(b.Versions, func(v VersionInfo) bool { return v.Version == version })
+	if idx < 0 {
+		return VersionInfo{}, false
+	}
+	return b.Versions[idx], true
+}
+
+// AddVersion tracks an installed version, replacing any previous install of the same version
+func (b *BinaryInfo) AddVersion(versionInfo VersionInfo) {
+	b.RemoveVersion(versionInfo.Version)
+	b.Versions = append(b.Versions, versionInfo)
+}
+
+func (b *BinaryInfo) RemoveVersion(version string) {
+	b.Versions = slices.DeleteFunc(b.Versions, func(v VersionInfo) bool { return v.Version == version })
+}
+
+// LastInstalledVersion returns the most recently installed version still tracked
+func (b BinaryInfo) LastInstalledVersion() string {
+	if len(b.Versions) == 0 {
+		return ""
+	}
+	last := slices.MaxFunc(b.Versions, func(a, b VersionInfo) int { return a.InstalledAt.Compare(b.InstalledAt) })
+	return last.Version
+}
+
+func (v VersionInfo) String() string {
+	details := make([]string, 0, 5)
+	if v.Path != "" {
+		details = append(details, v.Path)
+	}
+	if !v.InstalledAt.IsZero() {
+		details = append(details, "installed "+v.InstalledAt.Format(time.DateTime))
+	}
+	if v.URL != "" {
+		details = append(details, "from "+v.URL)
+	}
+	if v.Verified {
+		details = append(details, "checksum verified")
+	}
+	if v.Signature != "" {
+		details = append(details, "signed ("+v.Signature+")")
+	}
+
+	if len(details) == 0 {
+		return v.Version
+	}
+	return fmt.Sprintf("%s (%s)", v.Version, strings.Join(details, ", "))
+}
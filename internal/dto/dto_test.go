@@ -1,9 +1,12 @@
 package dto
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBinaryInfoString(t *testing.T) {
@@ -31,6 +34,37 @@ func TestBinaryInfoString(t *testing.T) {
 					InstalledVersion: "0.0.2",
 				},
 				expected: "foo/bar in version 0.0.2",
+			}, {
+				name: "active version different from installed one",
+				binaryInfo: BinaryInfo{
+					FullName:         "foo/foo",
+					Name:             "foo",
+					Owner:            "foo",
+					InstalledVersion: "0.0.2",
+					ActiveVersion:    "0.0.1",
+				},
+				expected: "foo in version 0.0.1 (latest installed 0.0.2)",
+			}, {
+				name: "constraint",
+				binaryInfo: BinaryInfo{
+					FullName:         "foo/foo",
+					Name:             "foo",
+					Owner:            "foo",
+					Constraint:       "~0.0",
+					InstalledVersion: "0.0.2",
+					ActiveVersion:    "0.0.1",
+				},
+				expected: "foo in version 0.0.1 (latest installed 0.0.2, constraint ~0.0)",
+			}, {
+				name: "pinned",
+				binaryInfo: BinaryInfo{
+					FullName:         "foo/foo",
+					Name:             "foo",
+					Owner:            "foo",
+					InstalledVersion: "0.0.2",
+					Pinned:           true,
+				},
+				expected: "foo in version 0.0.2 (pinned)",
 			}, {
 				name:       "empty",
 				binaryInfo: BinaryInfo{},
@@ -118,3 +152,82 @@ func TestDisplayName(t *testing.T) {
 		}
 	})
 }
+
+func TestVersions(t *testing.T) {
+	t.Run("should add, find and remove versions", func(t *testing.T) {
+		binaryInfo := BinaryInfo{FullName: "foo/foo", Name: "foo", Owner: "foo"}
+
+		binaryInfo.AddVersion(VersionInfo{Version: "0.0.1", Path: "/old"})
+		binaryInfo.AddVersion(VersionInfo{Version: "0.0.2"})
+		binaryInfo.AddVersion(VersionInfo{Version: "0.0.1", Path: "/new"})
+		assert.Len(t, binaryInfo.Versions, 2, "same version should be replaced")
+
+		versionInfo, ok := binaryInfo.FindVersion("0.0.1")
+		assert.True(t, ok)
+		assert.Equal(t, "/new", versionInfo.Path)
+
+		binaryInfo.RemoveVersion("0.0.1")
+		_, ok = binaryInfo.FindVersion("0.0.1")
+		assert.False(t, ok)
+		assert.Len(t, binaryInfo.Versions, 1)
+	})
+
+	t.Run("should return last installed version", func(t *testing.T) {
+		now := time.Now()
+		binaryInfo := BinaryInfo{
+			Versions: []VersionInfo{
+				{Version: "0.0.2", InstalledAt: now.Add(-time.Hour)},
+				{Version: "0.0.1", InstalledAt: now},
+				{Version: "0.0.3", InstalledAt: now.Add(-2 * time.Hour)},
+			},
+		}
+
+		assert.Equal(t, "0.0.1", binaryInfo.LastInstalledVersion())
+		assert.Empty(t, BinaryInfo{}.LastInstalledVersion())
+	})
+}
+
+func TestVersionInfoString(t *testing.T) {
+	installedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		name        string
+		versionInfo VersionInfo
+		expected    string
+	}{
+		{
+			name:        "version only",
+			versionInfo: VersionInfo{Version: "0.0.1"},
+			expected:    "0.0.1",
+		}, {
+			name: "all details",
+			versionInfo: VersionInfo{
+				Version: "0.0.1", Path: "/bin/foo-0.0.1",
+				InstalledAt: installedAt, URL: "https://foo.bar/foo",
+			},
+			expected: "0.0.1 (/bin/foo-0.0.1, installed 2025-01-02 03:04:05, from https://foo.bar/foo)",
+		}, {
+			name:        "verified",
+			versionInfo: VersionInfo{Version: "0.0.1", Verified: true, Signature: "cosign"},
+			expected:    "0.0.1 (checksum verified, signed (cosign))",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.versionInfo.String())
+		})
+	}
+}
+
+func TestChecksumURLNotSaved(t *testing.T) {
+	t.Run("should not marshal checksum and signature urls", func(t *testing.T) {
+		info := BinaryInfo{FullName: "foo/foo", ChecksumURL: "https://foo.bar/checksums.txt",
+			SignatureURLs: []string{"https://foo.bar/foo.sig"}}
+
+		data, err := json.Marshal(info)
+
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "checksums.txt")
+		assert.NotContains(t, string(data), "foo.sig")
+	})
+}
@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const MetadataCacheDirName = "http"
+
+// Refresh bypasses the cached metadata, the fresh responses are still cached
+var Refresh bool
+
+var cacheDir string
+
+// SetCacheDir sets the azabox cache directory, the metadata are cached in its http folder.
+// An empty directory disables the cache.
+func SetCacheDir(dir string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	cacheDir = dir
+}
+
+func metadataCacheDir() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(cacheDir, MetadataCacheDirName)
+}
+
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	StoredAt     time.Time `json:"storedAt"`
+	Body         []byte    `json:"body"`
+}
+
+// DoCached sends a metadata request with Do. Responses carrying an ETag or a Last-Modified are cached
+// on disk, later requests for the same url are conditional and a 304 is answered from the cache,
+// which GitHub does not count against the rate limit
+func DoCached(req *http.Request) (*http.Response, error) {
+	dir := metadataCacheDir()
+	if dir == "" {
+		return Do(req)
+	}
+
+	path := cachePath(dir, req.URL.String())
+	entry, cached := readCacheEntry(path)
+	if cached && Offline {
+		logging.Logger().Debug("offline, using cached response", "url", req.URL.Redacted(), "storedAt", entry.StoredAt)
+		return cachedResponse(req, entry), nil
+	}
+	if cached && !Refresh {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		logging.Logger().Debug("using cached response", "url", req.URL.Redacted(), "storedAt", entry.StoredAt)
+		cachedResp := cachedResponse(req, entry)
+		cachedResp.Header = resp.Header
+		return cachedResp, nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		entry = cacheEntry{
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StoredAt:     time.Now().UTC(),
+			Body:         body,
+		}
+		// the cache only saves requests, failing to write it must not fail the command
+		if err := writeCacheEntry(path, entry); err != nil {
+			logging.Logger().Debug("cannot write cache entry", "url", req.URL.Redacted(), "error", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+func cachedResponse(req *http.Request, entry cacheEntry) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+func cachePath(dir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func readCacheEntry(path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logging.Logger().Debug("ignoring invalid cache entry", "path", path, "error", err)
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeCacheEntry replaces the entry atomically, concurrent updates may write the same url
+func writeCacheEntry(path string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
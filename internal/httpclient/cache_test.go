@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func getCached(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := DoCached(newRequest(t, t.Context(), url))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestDoCached(t *testing.T) {
+	logging.UseInMemoryLogger()
+	var fetched, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/modified":
+			if r.Header.Get("If-Modified-Since") != "" {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		}
+		fetched.Add(1)
+		_, _ = io.WriteString(w, `{"tag_name":"v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	reset := func(t *testing.T) {
+		SetCacheDir(t.TempDir())
+		t.Cleanup(func() { SetCacheDir("") })
+		fetched.Store(0)
+		notModified.Store(0)
+	}
+
+	t.Run("should answer not modified responses from the cache", func(t *testing.T) {
+		for _, path := range []string{"/etag", "/modified"} {
+			reset(t)
+
+			_, body := getCached(t, server.URL+path)
+			status, cachedBody := getCached(t, server.URL+path)
+
+			assert.Equal(t, http.StatusOK, status, path)
+			assert.Equal(t, body, cachedBody, path)
+			assert.Equal(t, int32(1), fetched.Load(), path)
+			assert.Equal(t, int32(1), notModified.Load(), path)
+		}
+	})
+
+	t.Run("should not cache responses without validator", func(t *testing.T) {
+		reset(t)
+
+		getCached(t, server.URL+"/plain")
+		getCached(t, server.URL+"/plain")
+
+		assert.Equal(t, int32(2), fetched.Load())
+		assert.NoDirExists(t, metadataCacheDir())
+	})
+
+	t.Run("should bypass the cache on refresh", func(t *testing.T) {
+		reset(t)
+		Refresh = true
+		defer func() { Refresh = false }()
+
+		getCached(t, server.URL+"/etag")
+		getCached(t, server.URL+"/etag")
+
+		assert.Equal(t, int32(2), fetched.Load())
+		assert.Equal(t, int32(0), notModified.Load())
+	})
+
+	t.Run("should refetch when the cache entry is invalid", func(t *testing.T) {
+		reset(t)
+		getCached(t, server.URL+"/etag")
+		path := cachePath(metadataCacheDir(), server.URL+"/etag")
+		require.NoError(t, os.WriteFile(path, []byte("{invalid"), 0o600))
+
+		_, body := getCached(t, server.URL+"/etag")
+
+		assert.Equal(t, `{"tag_name":"v1.0.0"}`, body)
+		assert.Equal(t, int32(2), fetched.Load())
+	})
+
+	t.Run("should answer from the cache when offline", func(t *testing.T) {
+		reset(t)
+		_, body := getCached(t, server.URL+"/etag")
+		Offline = true
+		defer func() { Offline = false }()
+
+		status, cachedBody := getCached(t, server.URL+"/etag")
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, body, cachedBody)
+		assert.Equal(t, int32(1), fetched.Load())
+		assert.Equal(t, int32(0), notModified.Load())
+
+		_, err := DoCached(newRequest(t, t.Context(), server.URL+"/uncached"))
+		require.ErrorIs(t, err, ErrOffline)
+	})
+
+	t.Run("should work without cache directory", func(t *testing.T) {
+		SetCacheDir("")
+		fetched.Store(0)
+
+		getCached(t, server.URL+"/etag")
+		getCached(t, server.URL+"/etag")
+
+		assert.Equal(t, int32(2), fetched.Load())
+	})
+}
@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	OctetStreamHeader = "application/octet-stream"
+	UserAgentHeader   = "azabox"
+
+	// ResponseHeaderTimeout fails a request whose server never answers, a long download is not bounded
+	ResponseHeaderTimeout = time.Minute
+)
+
+// Offline forbids any network access, only the cached metadata and downloads are used
+var Offline bool
+
+var ErrOffline = errors.New("offline mode")
+
+// Client is shared by the resolvers and the installer so every request gets the same authentication
+var Client = &http.Client{Transport: &transport{base: newBaseTransport()}}
+
+func newBaseTransport() *http.Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = ResponseHeaderTimeout
+	return base
+}
+
+var (
+	mutex            sync.Mutex
+	githubToken      string
+	githubConfigured string
+	tokenDiscovered  bool
+	assets           = make(map[string]string)
+)
+
+// SetGitHubConfigToken sets the token of the configuration file, used when no environment variable is set
+func SetGitHubConfigToken(token string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	githubConfigured = token
+	tokenDiscovered = false
+}
+
+// SetGitHubToken forces the token used to authenticate GitHub API requests and asset downloads
+func SetGitHubToken(token string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	githubToken = token
+	tokenDiscovered = true
+}
+
+// GitHubToken returns the GitHub token, it is only discovered on first use so commands
+// without GitHub requests never ask gh for it
+func GitHubToken() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !tokenDiscovered {
+		githubToken = DiscoverGitHubToken(githubConfigured)
+		tokenDiscovered = true
+	}
+	return githubToken
+}
+
+// RedirectAsset makes the downloads of a release asset go through the API asset endpoint,
+// which unlike the browser download url accepts the token and so works for private repositories
+func RedirectAsset(downloadURL, apiURL string) {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	assets[parsed.String()] = apiURL
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mutex.Lock()
+	apiURL, ok := assets[req.URL.String()]
+	mutex.Unlock()
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	token := GitHubToken()
+	if token == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	// the original request is kept untouched, redirects to the storage are built from it and so
+	// never carry the token
+	apiReq := req.Clone(req.Context())
+	apiReq.URL = parsed
+	apiReq.Host = parsed.Host
+	apiReq.Header.Set("Accept", OctetStreamHeader)
+	apiReq.Header.Set("Authorization", "Bearer "+token)
+	apiReq.Header.Set("User-Agent", UserAgentHeader)
+	return t.base.RoundTrip(apiReq)
+}
@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := Client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/asset":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			assert.Equal(t, OctetStreamHeader, r.Header.Get("Accept"))
+			http.Redirect(w, r, "/storage", http.StatusFound)
+		case "/storage":
+			assert.Empty(t, r.Header.Get("Authorization"), "token should not follow the redirect")
+			_, _ = io.WriteString(w, "from api")
+		default:
+			assert.Empty(t, r.Header.Get("Authorization"))
+			_, _ = io.WriteString(w, "from browser url")
+		}
+	}))
+	defer server.Close()
+
+	t.Run("should download a registered asset through the API", func(t *testing.T) {
+		SetGitHubToken("secret")
+		defer SetGitHubToken("")
+		RedirectAsset(server.URL+"/download/tool", server.URL+"/api/asset")
+
+		assert.Equal(t, "from api", get(t, server.URL+"/download/tool"))
+		assert.Equal(t, "from browser url", get(t, server.URL+"/download/other"))
+	})
+
+	t.Run("should keep the browser url without token", func(t *testing.T) {
+		SetGitHubToken("")
+		RedirectAsset(server.URL+"/download/anonymous", server.URL+"/api/asset")
+
+		assert.Equal(t, "from browser url", get(t, server.URL+"/download/anonymous"))
+	})
+}
+
+func TestClient(t *testing.T) {
+	t.Run("should bound the wait for a response", func(t *testing.T) {
+		base := Client.Transport.(*transport).base.(*http.Transport)
+
+		assert.Equal(t, ResponseHeaderTimeout, base.ResponseHeaderTimeout)
+	})
+}
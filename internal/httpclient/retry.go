@@ -0,0 +1,181 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const (
+	MaxRetries = 3
+	// MaxRetryWait bounds the time waited before a retry, a longer rate limit reset fails right away
+	MaxRetryWait = time.Minute
+
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// RateLimitError is returned when the API rate limit is exhausted until a later reset
+type RateLimitError struct {
+	Host          string
+	Reset         time.Time
+	Authenticated bool
+}
+
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("rate limit of %s exceeded", e.Host)
+	if !e.Reset.IsZero() {
+		msg += fmt.Sprintf(", it resets at %s (in %s)", e.Reset.Local().Format(time.TimeOnly),
+			time.Until(e.Reset).Round(time.Second))
+	}
+	if !e.Authenticated {
+		msg += fmt.Sprintf(", set %s or %s to raise the limit", GitHubTokenEnvVar, GHTokenEnvVar)
+	}
+	return msg
+}
+
+// Do sends the request with Client, network errors, 429 and 5xx responses are retried with
+// a jittered backoff honouring Retry-After and the rate limit reset
+func Do(req *http.Request) (*http.Response, error) {
+	if Offline {
+		return nil, fmt.Errorf("%w, cannot fetch %s", ErrOffline, req.URL.Redacted())
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := Client.Do(req)
+		if err != nil && req.Context().Err() != nil {
+			return nil, err
+		}
+
+		delay, retry := retryDelay(resp, err, attempt)
+		if !retry || attempt >= MaxRetries || delay > MaxRetryWait {
+			if resp != nil && isRateLimited(resp) {
+				resp.Body.Close()
+				return nil, newRateLimitError(req, resp)
+			}
+			return resp, err
+		}
+
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logging.Logger().Debug("retrying request", "url", req.URL.Redacted(), "reason", reason,
+			"attempt", attempt+1, "delay", delay)
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay tells whether the attempt should be retried and how long to wait before
+func retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		// an unknown host does not show up by retrying
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+	if isRateLimited(resp) {
+		return untilReset(resp)
+	}
+	retryAfter, hasRetryAfter := parseRetryAfter(resp)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+	case resp.StatusCode == http.StatusForbidden && hasRetryAfter:
+		// secondary rate limits are reported as 403 with a Retry-After
+	default:
+		return 0, false
+	}
+	if hasRetryAfter {
+		return retryAfter, true
+	}
+	return backoff(attempt), true
+}
+
+// backoff doubles the delay on each attempt, the jitter keeps concurrent updates from retrying together
+func backoff(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func isRateLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get(RateLimitRemainingHeader) == "0"
+}
+
+func rateLimitReset(resp *http.Response) time.Time {
+	reset, err := strconv.ParseInt(resp.Header.Get(RateLimitResetHeader), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(reset, 0)
+}
+
+func untilReset(resp *http.Response) (time.Duration, bool) {
+	if retryAfter, ok := parseRetryAfter(resp); ok {
+		return retryAfter, true
+	}
+	reset := rateLimitReset(resp)
+	if reset.IsZero() {
+		return 0, false
+	}
+	// a second of margin so the retry does not hit the limit again
+	return max(time.Until(reset), 0) + time.Second, true
+}
+
+// parseRetryAfter reads Retry-After given either in seconds or as a date
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get(RetryAfterHeader)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func newRateLimitError(req *http.Request, resp *http.Response) *RateLimitError {
+	// the transport may send another request than req, an asset is downloaded through the API with the token
+	sent := req
+	if resp.Request != nil {
+		sent = resp.Request
+	}
+	return &RateLimitError{
+		Host:          sent.URL.Host,
+		Reset:         rateLimitReset(resp),
+		Authenticated: sent.Header.Get("Authorization") != "",
+	}
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
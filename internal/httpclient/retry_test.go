@@ -0,0 +1,193 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func withFastRetries(t *testing.T) {
+	t.Helper()
+	logging.UseInMemoryLogger()
+	saveBase, saveMax := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, 4*time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = saveBase, saveMax })
+}
+
+func newRequest(t *testing.T, ctx context.Context, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestDo(t *testing.T) {
+	t.Run("should retry server errors", func(t *testing.T) {
+		withFastRetries(t)
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch calls.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusBadGateway)
+			case 2:
+				w.Header().Set(RetryAfterHeader, "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			default:
+				_, _ = io.WriteString(w, "ok")
+			}
+		}))
+		defer server.Close()
+
+		resp, err := Do(newRequest(t, t.Context(), server.URL))
+
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("should give up after max retries", func(t *testing.T) {
+		withFastRetries(t)
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		resp, err := Do(newRequest(t, t.Context(), server.URL))
+
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(MaxRetries+1), calls.Load())
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		withFastRetries(t)
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.NotFound(w, r)
+		}))
+		defer server.Close()
+
+		resp, err := Do(newRequest(t, t.Context(), server.URL))
+
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("should report an exhausted rate limit", func(t *testing.T) {
+		withFastRetries(t)
+		reset := time.Now().Add(42 * time.Minute)
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set(RateLimitRemainingHeader, "0")
+			w.Header().Set(RateLimitResetHeader, strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		resp, err := Do(newRequest(t, t.Context(), server.URL))
+
+		var rateLimitErr *RateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		assert.Nil(t, resp)
+		assert.Equal(t, int32(1), calls.Load(), "a far reset should not be waited")
+		assert.Equal(t, reset.Unix(), rateLimitErr.Reset.Unix())
+		assert.Contains(t, err.Error(), "it resets at "+reset.Local().Format(time.TimeOnly))
+		assert.Contains(t, err.Error(), fmt.Sprintf("set %s or %s", GitHubTokenEnvVar, GHTokenEnvVar))
+
+		req := newRequest(t, t.Context(), server.URL)
+		req.Header.Set("Authorization", "Bearer secret")
+		_, err = Do(req)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), GitHubTokenEnvVar)
+	})
+
+	t.Run("should report an asset downloaded with the token as authenticated", func(t *testing.T) {
+		withFastRetries(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/asset", r.URL.Path)
+			w.Header().Set(RateLimitRemainingHeader, "0")
+			w.Header().Set(RateLimitResetHeader, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		SetGitHubToken("secret")
+		defer SetGitHubToken("")
+		RedirectAsset(server.URL+"/download/limited", server.URL+"/api/asset")
+
+		_, err := Do(newRequest(t, t.Context(), server.URL+"/download/limited"))
+
+		var rateLimitErr *RateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		assert.True(t, rateLimitErr.Authenticated)
+		assert.NotContains(t, err.Error(), GitHubTokenEnvVar)
+	})
+
+	t.Run("should stop retrying once cancelled", func(t *testing.T) {
+		withFastRetries(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := Do(newRequest(t, ctx, server.URL))
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("should grow with attempts and stay bounded", func(t *testing.T) {
+		for attempt := range 10 {
+			delay := backoff(attempt)
+			expected := min(retryBaseDelay<<attempt, retryMaxDelay)
+			assert.GreaterOrEqual(t, delay, expected/2)
+			assert.LessOrEqual(t, delay, expected)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "seconds", value: "3", expected: 3 * time.Second, ok: true},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", expected: 0, ok: true},
+		{name: "missing", value: "", ok: false},
+		{name: "invalid", value: "soon", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			resp.Header.Set(RetryAfterHeader, tc.value)
+
+			delay, ok := parseRetryAfter(resp)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, delay)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	GitHubTokenEnvVar = "GITHUB_TOKEN"
+	GHTokenEnvVar     = "GH_TOKEN"
+	GHConfigDirEnvVar = "GH_CONFIG_DIR"
+	GitHubHost        = "github.com"
+
+	ghAuthTokenTimeout = 5 * time.Second
+)
+
+// DiscoverGitHubToken looks for a GitHub token in GITHUB_TOKEN, GH_TOKEN, the configured token
+// and finally in the token stored by the gh cli, an empty token means anonymous requests
+func DiscoverGitHubToken(configToken string) string {
+	for _, envVar := range []string{GitHubTokenEnvVar, GHTokenEnvVar} {
+		if token := os.Getenv(envVar); token != "" {
+			logging.Logger().Debug("using GitHub token", "source", envVar)
+			return token
+		}
+	}
+	if configToken != "" {
+		logging.Logger().Debug("using GitHub token", "source", "config")
+		return configToken
+	}
+	if token := ghHostsToken(); token != "" {
+		logging.Logger().Debug("using GitHub token", "source", "gh hosts file")
+		return token
+	}
+	if token := ghAuthToken(); token != "" {
+		logging.Logger().Debug("using GitHub token", "source", "gh auth token")
+		return token
+	}
+	return ""
+}
+
+func ghConfigDir() string {
+	if dir := os.Getenv(GHConfigDirEnvVar); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "gh")
+}
+
+// ghHostsToken reads the token older gh versions store in plain text in hosts.yml
+func ghHostsToken() string {
+	dir := ghConfigDir()
+	if dir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(dir, "hosts.yml")))
+	if err != nil {
+		return ""
+	}
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		logging.Logger().Debug("invalid gh hosts file", "error", err)
+		return ""
+	}
+	return hosts[GitHubHost].OAuthToken
+}
+
+// ghAuthToken asks gh for its token, recent versions keep it in the system keyring
+func ghAuthToken() string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ghAuthTokenTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", GitHubHost).Output()
+	if err != nil {
+		logging.Logger().Debug("gh auth token failed", "error", err)
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func TestDiscoverGitHubToken(t *testing.T) {
+	logging.UseInMemoryLogger()
+	ghConfig := t.TempDir()
+	hosts := "github.com:\n    oauth_token: gh-hosts-token\n    user: foo\n"
+	require.NoError(t, os.WriteFile(filepath.Join(ghConfig, "hosts.yml"), []byte(hosts), 0o600))
+
+	testCases := []struct {
+		name        string
+		githubToken string
+		ghToken     string
+		configToken string
+		ghConfigDir string
+		expected    string
+	}{
+		{
+			name:        "should prefer GITHUB_TOKEN",
+			githubToken: "github-token",
+			ghToken:     "gh-token",
+			configToken: "config-token",
+			ghConfigDir: ghConfig,
+			expected:    "github-token",
+		},
+		{
+			name:        "should use GH_TOKEN",
+			ghToken:     "gh-token",
+			configToken: "config-token",
+			ghConfigDir: ghConfig,
+			expected:    "gh-token",
+		},
+		{
+			name:        "should use configured token",
+			configToken: "config-token",
+			ghConfigDir: ghConfig,
+			expected:    "config-token",
+		},
+		{
+			name:        "should read gh hosts file",
+			ghConfigDir: ghConfig,
+			expected:    "gh-hosts-token",
+		},
+		{
+			name:        "should handle no token",
+			ghConfigDir: t.TempDir(),
+			expected:    "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(GitHubTokenEnvVar, tc.githubToken)
+			t.Setenv(GHTokenEnvVar, tc.ghToken)
+			t.Setenv(GHConfigDirEnvVar, tc.ghConfigDir)
+			// gh must not be found so the token of the machine is never read
+			t.Setenv("PATH", t.TempDir())
+
+			assert.Equal(t, tc.expected, DiscoverGitHubToken(tc.configToken))
+		})
+	}
+}
+
+func TestGitHubToken(t *testing.T) {
+	t.Run("should discover the token once", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		t.Setenv(GitHubTokenEnvVar, "")
+		t.Setenv(GHTokenEnvVar, "")
+		t.Setenv(GHConfigDirEnvVar, t.TempDir())
+		t.Setenv("PATH", t.TempDir())
+		defer SetGitHubToken("")
+
+		SetGitHubConfigToken("config-token")
+		assert.Equal(t, "config-token", GitHubToken())
+
+		t.Setenv(GitHubTokenEnvVar, "github-token")
+		assert.Equal(t, "config-token", GitHubToken())
+	})
+}
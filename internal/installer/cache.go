@@ -0,0 +1,248 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const (
+	DownloadCacheDirName = "downloads"
+
+	blobsDirName    = "sha256"
+	urlsDirName     = "urls"
+	partialsDirName = "partial"
+)
+
+var ErrInvalidCachedDownload = errors.New("cached download does not match its digest")
+
+// DownloadCache keeps the downloaded assets by their SHA-256 digest so a version can be
+// installed again without downloading it, the urls are indexed to find their digest
+type DownloadCache struct {
+	dir     string
+	maxSize int64
+}
+
+// CachedDownload is the index entry of a url, it keeps the verification done on download
+type CachedDownload struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Verified  bool   `json:"verified"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// PruneResult tells what a prune removed
+type PruneResult struct {
+	Removed int
+	Freed   int64
+	Size    int64
+}
+
+func NewDownloadCache(cacheDir string, maxSize int64) *DownloadCache {
+	return &DownloadCache{
+		dir:     filepath.Join(cacheDir, DownloadCacheDirName),
+		maxSize: maxSize,
+	}
+}
+
+func (c *DownloadCache) blobPath(digest string) string {
+	return filepath.Join(c.dir, blobsDirName, digest)
+}
+
+func (c *DownloadCache) urlPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, urlsDirName, hex.EncodeToString(sum[:])+".json")
+}
+
+// partialPath is where the download of the url is written until it completes, an interrupted download is resumed
+// from it
+func (c *DownloadCache) partialPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, partialsDirName, hex.EncodeToString(sum[:]))
+}
+
+// Lookup returns the cached download of the url
+func (c *DownloadCache) Lookup(url string) (CachedDownload, bool) {
+	var entry CachedDownload
+	data, err := os.ReadFile(filepath.Clean(c.urlPath(url)))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url || !isSHA256(entry.SHA256) {
+		return entry, false
+	}
+	if _, err := os.Stat(c.blobPath(entry.SHA256)); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// CopyTo copies the cached download to path, its content is checked against its digest
+func (c *DownloadCache) CopyTo(entry CachedDownload, path string) error {
+	blobPath := c.blobPath(entry.SHA256)
+	in, err := os.Open(filepath.Clean(blobPath))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), in); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		_ = os.Remove(blobPath)
+		return fmt.Errorf("%w %s", ErrInvalidCachedDownload, entry.SHA256)
+	}
+	// the modification time orders the downloads to prune, the least recently used go first
+	now := time.Now()
+	_ = os.Chtimes(blobPath, now, now)
+	return nil
+}
+
+// Store adds the downloaded file to the cache, it is not pruned here since a concurrent install
+// may be about to copy a download out of it, see Trim
+func (c *DownloadCache) Store(entry CachedDownload, path string) error {
+	if err := os.MkdirAll(filepath.Join(c.dir, blobsDirName), 0o750); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, urlsDirName), 0o750); err != nil {
+		return err
+	}
+
+	blobPath := c.blobPath(entry.SHA256)
+	if _, err := os.Stat(blobPath); errors.Is(err, os.ErrNotExist) {
+		err := writeAtomically(blobPath, func(w io.Writer) error {
+			in, err := os.Open(filepath.Clean(path))
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			_, err = io.Copy(w, in)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeAtomically(c.urlPath(entry.URL), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Trim prunes the cache to its maximum size, it runs once the installs of a command are over
+func (c *DownloadCache) Trim() error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	_, err := c.Prune(c.maxSize)
+	return err
+}
+
+type cachedBlob struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Prune removes the least recently used downloads until the cache fits in maxSize,
+// the index entries of removed downloads are dropped as well
+func (c *DownloadCache) Prune(maxSize int64) (PruneResult, error) {
+	var result PruneResult
+	entries, err := os.ReadDir(filepath.Join(c.dir, blobsDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	blobs := make([]cachedBlob, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		blobs = append(blobs, cachedBlob{
+			path:    filepath.Join(c.dir, blobsDirName, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		result.Size += info.Size()
+	}
+	slices.SortFunc(blobs, func(a, b cachedBlob) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, blob := range blobs {
+		if result.Size <= maxSize {
+			break
+		}
+		if err := os.Remove(blob.path); err != nil {
+			return result, err
+		}
+		logging.Logger().Debug("pruned cached download", "path", blob.path, "size", blob.size)
+		result.Removed++
+		result.Freed += blob.size
+		result.Size -= blob.size
+	}
+
+	return result, c.removeDanglingUrls()
+}
+
+// removeDanglingUrls drops the index entries whose download is gone
+func (c *DownloadCache) removeDanglingUrls() error {
+	return filepath.WalkDir(filepath.Join(c.dir, urlsDirName), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		var entry CachedDownload
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err == nil && json.Unmarshal(data, &entry) == nil && isSHA256(entry.SHA256) {
+			if _, err := os.Stat(c.blobPath(entry.SHA256)); err == nil {
+				return nil
+			}
+		}
+		return os.Remove(path)
+	})
+}
+
+// writeAtomically writes the file through a temporary file so a concurrent reader never sees a partial file
+func writeAtomically(path string, write func(io.Writer) error) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if err := write(tmpFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
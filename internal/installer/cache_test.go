@@ -0,0 +1,177 @@
+package installer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func storeTestDownload(t *testing.T, cache *DownloadCache, url, content string) CachedDownload {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "asset")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	entry := CachedDownload{URL: url, SHA256: testDigest(content), Verified: true}
+	require.NoError(t, cache.Store(entry, path))
+	return entry
+}
+
+func TestDownloadCache(t *testing.T) {
+	logging.UseInMemoryLogger()
+
+	t.Run("should store and copy a download", func(t *testing.T) {
+		cache := NewDownloadCache(t.TempDir(), 0)
+		stored := storeTestDownload(t, cache, "https://foo.bar/tool", "binary data")
+
+		entry, ok := cache.Lookup("https://foo.bar/tool")
+		require.True(t, ok)
+		assert.Equal(t, stored, entry)
+		target := filepath.Join(t.TempDir(), "tool")
+		require.NoError(t, cache.CopyTo(entry, target))
+		data, err := os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "binary data", string(data))
+
+		_, ok = cache.Lookup("https://foo.bar/other")
+		assert.False(t, ok)
+	})
+
+	t.Run("should share the content of identical downloads", func(t *testing.T) {
+		cache := NewDownloadCache(t.TempDir(), 0)
+		storeTestDownload(t, cache, "https://foo.bar/tool", "binary data")
+		storeTestDownload(t, cache, "https://mirror.foo.bar/tool", "binary data")
+
+		blobs, err := os.ReadDir(filepath.Join(cache.dir, blobsDirName))
+		require.NoError(t, err)
+		assert.Len(t, blobs, 1)
+	})
+
+	t.Run("should reject a corrupted download", func(t *testing.T) {
+		cache := NewDownloadCache(t.TempDir(), 0)
+		entry := storeTestDownload(t, cache, "https://foo.bar/tool", "binary data")
+		require.NoError(t, os.WriteFile(cache.blobPath(entry.SHA256), []byte("tampered"), 0o600))
+
+		err := cache.CopyTo(entry, filepath.Join(t.TempDir(), "tool"))
+
+		require.ErrorIs(t, err, ErrInvalidCachedDownload)
+		_, ok := cache.Lookup("https://foo.bar/tool")
+		assert.False(t, ok, "corrupted download should be removed")
+	})
+
+	t.Run("should prune least recently used downloads", func(t *testing.T) {
+		cache := NewDownloadCache(t.TempDir(), 0)
+		old := storeTestDownload(t, cache, "https://foo.bar/old", "old binary")
+		recent := storeTestDownload(t, cache, "https://foo.bar/recent", "recent binary")
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(cache.blobPath(old.SHA256), past, past))
+
+		result, err := cache.Prune(int64(len("recent binary")))
+
+		require.NoError(t, err)
+		assert.Equal(t, PruneResult{Removed: 1, Freed: int64(len("old binary")), Size: int64(len("recent binary"))},
+			result)
+		_, ok := cache.Lookup(old.URL)
+		assert.False(t, ok)
+		_, ok = cache.Lookup(recent.URL)
+		assert.True(t, ok)
+		urls, err := os.ReadDir(filepath.Join(cache.dir, urlsDirName))
+		require.NoError(t, err)
+		assert.Len(t, urls, 1, "index entry of the pruned download should be removed")
+	})
+
+	t.Run("should only prune above the maximum size once trimmed", func(t *testing.T) {
+		cache := NewDownloadCache(t.TempDir(), int64(len("second binary")))
+		first := storeTestDownload(t, cache, "https://foo.bar/first", "first binary")
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(cache.blobPath(first.SHA256), past, past))
+		second := storeTestDownload(t, cache, "https://foo.bar/second", "second binary")
+
+		_, ok := cache.Lookup(first.URL)
+		assert.True(t, ok, "a store should not evict a download a concurrent install may use")
+
+		require.NoError(t, cache.Trim())
+		_, ok = cache.Lookup(first.URL)
+		assert.False(t, ok)
+		_, ok = cache.Lookup(second.URL)
+		assert.True(t, ok)
+	})
+
+	t.Run("should handle empty cache", func(t *testing.T) {
+		result, err := NewDownloadCache(t.TempDir(), 0).Prune(0)
+
+		require.NoError(t, err)
+		assert.Equal(t, PruneResult{}, result)
+	})
+}
+
+func TestInstallFromCache(t *testing.T) {
+	logging.UseInMemoryLogger()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = io.WriteString(w, testBinaryData)
+	}))
+	defer server.Close()
+
+	newCachedInstaller := func(t *testing.T) (*LocalInstaller, *dto.BinaryInfo) {
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(t.TempDir()).WithInstallFolder(t.TempDir()).
+			WithDownloadCache(NewDownloadCache(t.TempDir(), 0))
+		requests.Store(0)
+		return downloader, &dto.BinaryInfo{Name: "tool", FullName: "user/tool", InstalledVersion: "v1.0.0"}
+	}
+
+	t.Run("should reinstall a removed version offline", func(t *testing.T) {
+		downloader, binaryInfo := newCachedInstaller(t)
+		require.NoError(t, downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool"))
+		require.NoError(t, downloader.Uninstall(binaryInfo, "v1.0.0"))
+		httpclient.Offline = true
+		defer func() { httpclient.Offline = false }()
+
+		var out strings.Builder
+		err := downloader.Install(t.Context(), &out, binaryInfo, server.URL+"/tool")
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Contains(t, out.String(), "Using cached user/tool - v1.0.0 (sha256 "+testDigest(testBinaryData)+")")
+		assert.True(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		versionInfo, ok := binaryInfo.FindVersion("v1.0.0")
+		require.True(t, ok)
+		assert.Equal(t, testDigest(testBinaryData), versionInfo.SHA256)
+	})
+
+	t.Run("should fail offline without cached download", func(t *testing.T) {
+		downloader, binaryInfo := newCachedInstaller(t)
+		httpclient.Offline = true
+		defer func() { httpclient.Offline = false }()
+
+		err := downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.ErrorIs(t, err, httpclient.ErrOffline)
+		assert.Equal(t, int32(0), requests.Load())
+	})
+
+	t.Run("should download again when the cached download was not verified as required", func(t *testing.T) {
+		downloader, binaryInfo := newCachedInstaller(t)
+		require.NoError(t, downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool"))
+		RequireChecksum = true
+		defer func() { RequireChecksum = false }()
+
+		err := downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.ErrorIs(t, err, ErrNoChecksum)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
@@ -0,0 +1,170 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+// RequireChecksum refuses to install assets that could not be verified against a published checksum
+var RequireChecksum bool
+
+var (
+	ErrNoChecksum       = errors.New("no checksum published")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isSHA256(digest string) bool {
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+// parseChecksums finds the digest of fileName in a checksum file, the GNU ("<digest>  <file>"),
+// BSD ("SHA256 (<file>) = <digest>") and single digest formats are supported
+func parseChecksums(r io.Reader, fileName string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	var lines [][]string
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 1 && isSHA256(lines[0][0]) && len(lines[0]) == 1 {
+		return strings.ToLower(lines[0][0]), nil
+	}
+
+	for _, fields := range lines {
+		var digest, name string
+		switch {
+		case len(fields) == 4 && fields[0] == "SHA256" && fields[2] == "=":
+			digest = fields[3]
+			name = strings.TrimSuffix(strings.TrimPrefix(fields[1], "("), ")")
+		case len(fields) == 2:
+			digest = fields[0]
+			name = strings.TrimPrefix(fields[1], "*")
+		default:
+			continue
+		}
+		if isSHA256(digest) && path.Base(name) == fileName {
+			return strings.ToLower(digest), nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s", ErrNoChecksum, fileName)
+}
+
+// fetchChecksum returns the digest of fileName along with the checksum file it was found in
+func fetchChecksum(ctx context.Context, checksumURL, fileName string) (string, []byte, error) {
+	logging.Logger().Debug("Fetching checksum", "url", checksumURL, "file", fileName)
+	checksums, err := fetchAsset(ctx, checksumURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("checksum download failed: %w", err)
+	}
+	digest, err := parseChecksums(bytes.NewReader(checksums), fileName)
+	if err != nil {
+		return "", nil, err
+	}
+	return digest, checksums, nil
+}
+
+// lockedChecksums fetches the locked checksum file, it must list the locked digest so that the signature
+// of the checksum file covers the asset
+func lockedChecksums(ctx context.Context, binaryInfo *dto.BinaryInfo, fileName string) ([]byte, error) {
+	if binaryInfo.ChecksumURL == "" {
+		return nil, nil
+	}
+	published, checksums, err := fetchChecksum(ctx, binaryInfo.ChecksumURL, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if published != binaryInfo.LockedSHA256 {
+		return nil, fmt.Errorf("%w for %s: the checksum file lists %s, locked %s", ErrChecksumMismatch, fileName,
+			published, binaryInfo.LockedSHA256)
+	}
+	return checksums, nil
+}
+
+// checksumResult is the outcome of verifyChecksum, checksums is the checksum file the asset matched
+// so its signature is verified over these very bytes rather than over a second download
+type checksumResult struct {
+	digest    string
+	verified  bool
+	checksums []byte
+}
+
+// verifyChecksum computes the digest of the downloaded asset and compares it with the published one
+func verifyChecksum(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url, tmpFile string) (
+	checksumResult, error,
+) {
+	digest, err := fileSHA256(tmpFile)
+	if err != nil {
+		return checksumResult{}, err
+	}
+
+	fileName := getFileName(url)
+	expected := ""
+	var checksums []byte
+	switch {
+	case binaryInfo.LockedSHA256 != "":
+		// the lockfile digest pins the asset, whatever the release publishes
+		expected = binaryInfo.LockedSHA256
+		if checksums, err = lockedChecksums(ctx, binaryInfo, fileName); err != nil {
+			return checksumResult{}, err
+		}
+	case binaryInfo.ChecksumURL == "":
+		err = fmt.Errorf("%w for %s", ErrNoChecksum, fileName)
+	default:
+		expected, checksums, err = fetchChecksum(ctx, binaryInfo.ChecksumURL, fileName)
+	}
+	if errors.Is(err, ErrNoChecksum) && !RequireChecksum {
+		logging.Logger().Debug("Skipping checksum verification", "file", fileName, "reason", err)
+		fmt.Fprintf(out, "No checksum published for %s, skipping verification\n", fileName)
+		return checksumResult{digest: digest}, nil
+	}
+	if errors.Is(err, ErrNoChecksum) {
+		return checksumResult{}, fmt.Errorf("%w, refusing to install an unverified asset", err)
+	}
+	if err != nil {
+		return checksumResult{}, err
+	}
+
+	if digest != expected {
+		return checksumResult{}, fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, fileName,
+			expected, digest)
+	}
+	logging.Logger().Debug("Checksum verified", "file", fileName, "sha256", digest)
+	fmt.Fprintln(out, "Checksum verified (sha256 "+digest+")")
+	return checksumResult{digest: digest, verified: true, checksums: checksums}, nil
+}
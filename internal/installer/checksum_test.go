@@ -0,0 +1,241 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const testBinaryData = "binary data"
+
+func testDigest(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func newChecksumTestServer(t *testing.T, checksums string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "checksums.txt") {
+			_, err := io.WriteString(w, checksums)
+			require.NoError(t, err)
+			return
+		}
+		_, err := io.WriteString(w, testBinaryData)
+		require.NoError(t, err)
+	}))
+}
+
+func TestParseChecksums(t *testing.T) {
+	digest := testDigest(testBinaryData)
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "gnu format", content: digest + "  tool_linux_amd64.tar.gz\n", expected: digest},
+		{name: "gnu binary mode", content: digest + " *tool_linux_amd64.tar.gz\n", expected: digest},
+		{name: "bsd format", content: "SHA256 (tool_linux_amd64.tar.gz) = " + digest + "\n", expected: digest},
+		{name: "single digest", content: strings.ToUpper(digest) + "\n", expected: digest},
+		{name: "relative path", content: digest + "  ./dist/tool_linux_amd64.tar.gz\n", expected: digest},
+		{
+			name: "several files",
+			content: testDigest("other") + "  tool_darwin_arm64.tar.gz\n" +
+				digest + "  tool_linux_amd64.tar.gz\n",
+			expected: digest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseChecksums(strings.NewReader(tc.content), "tool_linux_amd64.tar.gz")
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	t.Run("should handle missing file", func(t *testing.T) {
+		_, err := parseChecksums(strings.NewReader(digest+"  tool_darwin_arm64.tar.gz\n"), "tool_linux_amd64.tar.gz")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNoChecksum)
+	})
+
+	t.Run("should ignore invalid digest", func(t *testing.T) {
+		_, err := parseChecksums(strings.NewReader("foo  tool_linux_amd64.tar.gz\n"), "tool_linux_amd64.tar.gz")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNoChecksum)
+	})
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	logging.UseInMemoryLogger()
+	tmpFile := filepath.Join(t.TempDir(), "azabox-tool")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(testBinaryData), 0o600))
+	digest := testDigest(testBinaryData)
+
+	t.Run("should verify against published checksum", func(t *testing.T) {
+		server := newChecksumTestServer(t, digest+"  tool\n")
+		defer server.Close()
+		binaryInfo := &dto.BinaryInfo{Name: "tool", ChecksumURL: server.URL + "/checksums.txt"}
+
+		got, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+
+		require.NoError(t, err)
+		assert.True(t, got.verified)
+		assert.Equal(t, digest, got.digest)
+		assert.Equal(t, []byte(digest+"  tool\n"), got.checksums, "verified checksum file should be returned")
+	})
+
+	t.Run("should handle mismatch", func(t *testing.T) {
+		server := newChecksumTestServer(t, testDigest("tampered")+"  tool\n")
+		defer server.Close()
+		binaryInfo := &dto.BinaryInfo{Name: "tool", ChecksumURL: server.URL + "/checksums.txt"}
+
+		got, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		assert.False(t, got.verified)
+	})
+
+	t.Run("should verify against the locked digest", func(t *testing.T) {
+		RequireChecksum = true
+		defer func() { RequireChecksum = false }()
+		binaryInfo := &dto.BinaryInfo{Name: "tool", LockedSHA256: digest}
+
+		got, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, "https://foo.bar/tool", tmpFile)
+
+		require.NoError(t, err)
+		assert.True(t, got.verified)
+		assert.Equal(t, digest, got.digest)
+		assert.Nil(t, got.checksums)
+	})
+
+	t.Run("should return the locked checksum file listing the locked digest", func(t *testing.T) {
+		server := newChecksumTestServer(t, digest+"  tool\n")
+		defer server.Close()
+		binaryInfo := &dto.BinaryInfo{Name: "tool", LockedSHA256: digest, ChecksumURL: server.URL + "/checksums.txt"}
+
+		got, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+
+		require.NoError(t, err)
+		assert.True(t, got.verified)
+		assert.Equal(t, []byte(digest+"  tool\n"), got.checksums, "its signature is verified over it")
+	})
+
+	t.Run("should refuse a locked checksum file not listing the locked digest", func(t *testing.T) {
+		for _, checksums := range []string{testDigest("other") + "  tool\n", digest + "  other\n"} {
+			server := newChecksumTestServer(t, checksums)
+			binaryInfo := &dto.BinaryInfo{Name: "tool", LockedSHA256: digest,
+				ChecksumURL: server.URL + "/checksums.txt"}
+
+			_, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+			server.Close()
+
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("should handle mismatch with the locked digest", func(t *testing.T) {
+		binaryInfo := &dto.BinaryInfo{Name: "tool", LockedSHA256: testDigest("tampered")}
+
+		_, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, "https://foo.bar/tool", tmpFile)
+
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("should skip verification without checksum", func(t *testing.T) {
+		binaryInfo := &dto.BinaryInfo{Name: "tool"}
+
+		got, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, "https://foo.bar/tool", tmpFile)
+
+		require.NoError(t, err)
+		assert.False(t, got.verified)
+		assert.Equal(t, digest, got.digest, "digest should still be computed")
+	})
+
+	t.Run("should refuse unverified asset when checksum is required", func(t *testing.T) {
+		RequireChecksum = true
+		defer func() { RequireChecksum = false }()
+		server := newChecksumTestServer(t, digest+"  other\n")
+		defer server.Close()
+
+		for _, checksumURL := range []string{"", server.URL + "/checksums.txt"} {
+			binaryInfo := &dto.BinaryInfo{Name: "tool", ChecksumURL: checksumURL}
+
+			_, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrNoChecksum)
+		}
+	})
+
+	t.Run("should handle checksum download error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		binaryInfo := &dto.BinaryInfo{Name: "tool", ChecksumURL: server.URL + "/checksums.txt"}
+
+		_, err := verifyChecksum(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpFile)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
+	})
+}
+
+func TestInstallWithChecksum(t *testing.T) {
+	t.Run("should store verified digest", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		digest := testDigest(testBinaryData)
+		server := newChecksumTestServer(t, fmt.Sprintf("%s  tool\n", digest))
+		defer server.Close()
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			ChecksumURL: server.URL + "/checksums.txt"}
+
+		err = downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.NoError(t, err)
+		versionInfo, ok := binaryInfo.FindVersion("v1.0.0")
+		require.True(t, ok)
+		assert.Equal(t, digest, versionInfo.SHA256)
+		assert.True(t, versionInfo.Verified)
+	})
+
+	t.Run("should not install on mismatch", func(t *testing.T) {
+		server := newChecksumTestServer(t, testDigest("tampered")+"  tool\n")
+		defer server.Close()
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			ChecksumURL: server.URL + "/checksums.txt"}
+
+		err = downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		assert.False(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		entries, err := os.ReadDir(tmpDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "download should be removed")
+		assert.Empty(t, binaryInfo.Versions)
+	})
+}
@@ -0,0 +1,227 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+var errDownloadInterrupted = errors.New("download interrupted")
+
+// partialDownload identifies the content of a partial download, a resumed download must match it
+type partialDownload struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// downloadToTmpDir downloads the asset in the temporary folder. With a download cache the asset is written
+// in the cache first, an interrupted download is resumed there with a Range request, now or on a later install.
+// Without cache a partial download is removed
+func (l *LocalInstaller) downloadToTmpDir(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url,
+	tmpDir string,
+) (string, error) {
+	logging.Logger().Debug("Downloading", "url", url, "binary", binaryInfo.Name, "owner",
+		binaryInfo.Owner, "version", binaryInfo.InstalledVersion)
+	fmt.Fprintf(out, "Downloading %s - %s\n", binaryInfo.FullName, binaryInfo.InstalledVersion)
+
+	target := filepath.Clean(filepath.Join(tmpDir, getFileName(url)))
+	if l.cache == nil {
+		if err := downloadFile(ctx, out, url, target, false); err != nil {
+			_ = os.Remove(target)
+			return "", err
+		}
+		return target, nil
+	}
+
+	partial := l.cache.partialPath(url)
+	if err := os.MkdirAll(filepath.Dir(partial), 0o750); err != nil {
+		return "", err
+	}
+	for attempt := 0; ; attempt++ {
+		err := downloadFile(ctx, out, url, partial, true)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errDownloadInterrupted) || ctx.Err() != nil || attempt >= httpclient.MaxRetries {
+			return "", err
+		}
+		logging.Logger().Debug("resuming interrupted download", "url", url, "attempt", attempt+1, "error", err)
+	}
+	if err := moveFile(partial, target); err != nil {
+		return "", err
+	}
+	_ = os.Remove(partial + ".json")
+	return target, nil
+}
+
+// downloadFile writes the url to path, when resume is set it continues the partial content of path
+// as long as the server still holds the same content
+func downloadFile(ctx context.Context, out io.Writer, url, path string, resume bool) error {
+	var offset int64
+	if resume {
+		offset = partialSize(path, url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		partial, _ := readPartialDownload(path)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// If-Range answers the whole content instead of a range when the asset changed
+		if partial.ETag != "" {
+			req.Header.Set("If-Range", partial.ETag)
+		} else {
+			req.Header.Set("If-Range", partial.LastModified)
+		}
+	}
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			return fmt.Errorf("download failed: unexpected range %q", resp.Header.Get("Content-Range"))
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		fmt.Fprintf(out, "Resuming download at %s\n", config.FormatSize(offset))
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the partial download does not belong to the current asset, start over
+		resp.Body.Close()
+		removePartialDownload(path)
+		return downloadFile(ctx, out, url, path, resume)
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	if resume && offset == 0 {
+		removePartialDownload(path)
+		partial := partialDownload{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if err := writePartialDownload(path, partial); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(filepath.Clean(path), flags, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := newProgress(out, offset, total)
+	_, err = io.Copy(io.MultiWriter(file, progress), resp.Body)
+	progress.Done(err)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errDownloadInterrupted, err)
+	}
+	return file.Close()
+}
+
+// partialSize returns the size of a resumable partial download of the url, a partial download
+// without validator cannot be resumed safely
+func partialSize(path, url string) int64 {
+	partial, err := readPartialDownload(path)
+	if err != nil || partial.URL != url || (partial.ETag == "" && partial.LastModified == "") {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func readPartialDownload(path string) (partialDownload, error) {
+	var partial partialDownload
+	data, err := os.ReadFile(filepath.Clean(path + ".json"))
+	if err != nil {
+		return partial, err
+	}
+	err = json.Unmarshal(data, &partial)
+	return partial, err
+}
+
+func writePartialDownload(path string, partial partialDownload) error {
+	data, err := json.Marshal(partial)
+	if err != nil {
+		return err
+	}
+	return writeAtomically(path+".json", func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func removePartialDownload(path string) {
+	_ = os.Remove(path)
+	_ = os.Remove(path + ".json")
+}
+
+// contentRangeStart returns the first byte of a "bytes start-end/size" Content-Range
+func contentRangeStart(contentRange string) int64 {
+	byteRange, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return -1
+	}
+	value, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// moveFile renames the file, copying it when both paths are not on the same file system
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+	in, err := os.Open(filepath.Clean(source))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(filepath.Clean(target))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
@@ -0,0 +1,171 @@
+package installer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const testLargeData = "large binary data split by a dropped connection"
+
+func TestResumeDownload(t *testing.T) {
+	logging.UseInMemoryLogger()
+	binaryInfo := &dto.BinaryInfo{Name: "tool", FullName: "user/tool", InstalledVersion: "v1.0.0"}
+	modTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newResumingInstaller := func(t *testing.T) (*LocalInstaller, *DownloadCache) {
+		cache := NewDownloadCache(t.TempDir(), 0)
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(t.TempDir()).WithInstallFolder(t.TempDir()).WithDownloadCache(cache)
+		return downloader, cache
+	}
+
+	t.Run("should resume a dropped download", func(t *testing.T) {
+		var requests atomic.Int32
+		var ranges []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			if requests.Add(1) == 1 {
+				w.Header().Set("Content-Length", strconv.Itoa(len(testLargeData)))
+				_, _ = io.WriteString(w, testLargeData[:10])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			ranges = append(ranges, r.Header.Get("Range"))
+			http.ServeContent(w, r, "tool", modTime, strings.NewReader(testLargeData))
+		}))
+		defer server.Close()
+		downloader, cache := newResumingInstaller(t)
+		tmpDir := t.TempDir()
+
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, server.URL+"/tool", tmpDir)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(tmpDir, "tool"), file)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testLargeData, string(data))
+		assert.Equal(t, []string{"bytes=10-"}, ranges)
+		assert.NoFileExists(t, cache.partialPath(server.URL+"/tool"))
+	})
+
+	t.Run("should resume a partial download of a previous install", func(t *testing.T) {
+		var ranges []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "tool", modTime, strings.NewReader(testLargeData))
+		}))
+		defer server.Close()
+		downloader, cache := newResumingInstaller(t)
+		url := server.URL + "/tool"
+		partial := cache.partialPath(url)
+		require.NoError(t, os.MkdirAll(filepath.Dir(partial), 0o750))
+		require.NoError(t, os.WriteFile(partial, []byte(testLargeData[:20]), 0o600))
+		require.NoError(t, writePartialDownload(partial, partialDownload{URL: url, ETag: `"v1"`}))
+		var out strings.Builder
+
+		file, err := downloader.downloadToTmpDir(t.Context(), &out, binaryInfo, url, t.TempDir())
+
+		require.NoError(t, err)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testLargeData, string(data))
+		assert.Equal(t, []string{"bytes=20-"}, ranges)
+		assert.Contains(t, out.String(), "Resuming download at 20B")
+		assert.Contains(t, out.String(), "Downloaded 47B")
+	})
+
+	t.Run("should start over when the asset changed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v2"`)
+			http.ServeContent(w, r, "tool", modTime, strings.NewReader(testLargeData))
+		}))
+		defer server.Close()
+		downloader, cache := newResumingInstaller(t)
+		url := server.URL + "/tool"
+		partial := cache.partialPath(url)
+		require.NoError(t, os.MkdirAll(filepath.Dir(partial), 0o750))
+		require.NoError(t, os.WriteFile(partial, []byte("stale content"), 0o600))
+		require.NoError(t, writePartialDownload(partial, partialDownload{URL: url, ETag: `"v1"`}))
+
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, url, t.TempDir())
+
+		require.NoError(t, err)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testLargeData, string(data))
+	})
+
+	t.Run("should start over when the range cannot be satisfied", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "tool", modTime, strings.NewReader(testLargeData))
+		}))
+		defer server.Close()
+		downloader, cache := newResumingInstaller(t)
+		url := server.URL + "/tool"
+		partial := cache.partialPath(url)
+		require.NoError(t, os.MkdirAll(filepath.Dir(partial), 0o750))
+		require.NoError(t, os.WriteFile(partial, []byte(testLargeData+" and more"), 0o600))
+		require.NoError(t, writePartialDownload(partial, partialDownload{URL: url, ETag: `"v1"`}))
+
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, url, t.TempDir())
+
+		require.NoError(t, err)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testLargeData, string(data))
+	})
+
+	t.Run("should keep the partial download when it cannot be resumed now", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", strconv.Itoa(len(testLargeData)))
+			_, _ = io.WriteString(w, testLargeData[:10])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}))
+		defer server.Close()
+		downloader, cache := newResumingInstaller(t)
+		url := server.URL + "/tool"
+
+		_, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, url, t.TempDir())
+
+		require.ErrorIs(t, err, errDownloadInterrupted)
+		assert.Equal(t, int64(10), partialSize(cache.partialPath(url), url))
+	})
+}
+
+func TestContentRangeStart(t *testing.T) {
+	testCases := []struct {
+		name         string
+		contentRange string
+		expected     int64
+	}{
+		{name: "should parse range", contentRange: "bytes 10-46/47", expected: 10},
+		{name: "should parse range of unknown size", contentRange: "bytes 0-46/*", expected: 0},
+		{name: "should handle unknown unit", contentRange: "items 10-46/47", expected: -1},
+		{name: "should handle invalid range", contentRange: "bytes ten-46/47", expected: -1},
+		{name: "should handle empty range", contentRange: "", expected: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, contentRangeStart(tc.contentRange))
+		})
+	}
+}
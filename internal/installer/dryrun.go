@@ -0,0 +1,53 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+// DryRun makes the installer report what it would do, nothing is downloaded nor written
+var DryRun bool
+
+func (l *LocalInstaller) dryRunInstall(out io.Writer, binaryInfo *dto.BinaryInfo, url string, link bool) error {
+	targetPath := l.BinaryPath(binaryInfo, binaryInfo.InstalledVersion)
+	logging.Logger().Debug("dry run install", "url", url, "binary", binaryInfo.Name,
+		"version", binaryInfo.InstalledVersion, "path", targetPath)
+
+	fmt.Fprintf(out, "Would install %s version %s from %s\n", binaryInfo.DisplayName(),
+		binaryInfo.InstalledVersion, binaryInfo.Resolver)
+	fmt.Fprintf(out, "  asset:   %s\n", url)
+	fmt.Fprintf(out, "  target:  %s\n", targetPath)
+	if link {
+		fmt.Fprintf(out, "  symlink: %s\n", l.symlinkChange(binaryInfo, targetPath))
+	}
+	return nil
+}
+
+func (l *LocalInstaller) dryRunLink(binaryInfo *dto.BinaryInfo, version string) error {
+	targetPath := l.BinaryPath(binaryInfo, version)
+	fmt.Fprintf(l.out, "Would link %s to version %s\n", binaryInfo.DisplayName(), version)
+	fmt.Fprintf(l.out, "  symlink: %s\n", l.symlinkChange(binaryInfo, targetPath))
+	return nil
+}
+
+// symlinkChange describes how the binary symlink would move to target, in shim mode it points to azabox
+func (l *LocalInstaller) symlinkChange(binaryInfo *dto.BinaryInfo, target string) string {
+	symLinkPath := filepath.Join(l.installFolder, binaryInfo.Name)
+	if l.shimTarget != "" {
+		target = l.shimTarget
+	}
+	current, err := os.Readlink(symLinkPath)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s -> %s (new)", symLinkPath, target)
+	case current == target:
+		return fmt.Sprintf("%s -> %s (unchanged)", symLinkPath, target)
+	default:
+		return fmt.Sprintf("%s -> %s (was %s)", symLinkPath, target, current)
+	}
+}
@@ -0,0 +1,93 @@
+package installer
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func TestDryRun(t *testing.T) {
+	DryRun = true
+	defer func() { DryRun = false }()
+
+	t.Run("should not download nor install", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		downloaded := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			downloaded = true
+		}))
+		defer server.Close()
+
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			Resolver: "github"}
+
+		var out bytes.Buffer
+		err = downloader.Install(t.Context(), &out, binaryInfo, server.URL+"/tool")
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "Would install user/tool version v1.0.0 from github")
+		assert.Contains(t, out.String(), "asset:   "+server.URL+"/tool")
+		assert.False(t, downloaded)
+		assert.Empty(t, binaryInfo.Versions)
+		entries, err := os.ReadDir(tmpDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should leave binaries and symlink in place", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		var out bytes.Buffer
+		downloader.WithInstallFolder(tmpDir).WithOutput(&out)
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "tool"}
+		target := filepath.Join(tmpDir, "tool-v1.0.0")
+		symLinkPath := filepath.Join(tmpDir, "tool")
+		require.NoError(t, os.WriteFile(target, []byte("binary content"), 0o600))
+		require.NoError(t, os.Symlink(target, symLinkPath))
+
+		require.NoError(t, downloader.Link(binaryInfo, "v2.0.0"))
+		require.NoError(t, downloader.Unlink(binaryInfo))
+		require.NoError(t, downloader.Uninstall(binaryInfo, "v1.0.0"))
+
+		got, err := os.Readlink(symLinkPath)
+		require.NoError(t, err)
+		assert.Equal(t, target, got)
+		assert.FileExists(t, target)
+		assert.Equal(t, "Would link tool to version v2.0.0\n"+
+			"  symlink: "+symLinkPath+" -> "+filepath.Join(tmpDir, "tool-v2.0.0")+" (was "+target+")\n"+
+			"Would remove symlink "+symLinkPath+"\n"+
+			"Would remove "+target+"\n", out.String())
+	})
+}
+
+func TestSymlinkChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	downloader, err := New()
+	require.NoError(t, err)
+	downloader.WithInstallFolder(tmpDir)
+	binaryInfo := &dto.BinaryInfo{Name: "tool"}
+	symLinkPath := filepath.Join(tmpDir, "tool")
+
+	assert.Equal(t, symLinkPath+" -> /new (new)", downloader.symlinkChange(binaryInfo, "/new"))
+
+	require.NoError(t, os.Symlink("/old", symLinkPath))
+	assert.Equal(t, symLinkPath+" -> /old (unchanged)", downloader.symlinkChange(binaryInfo, "/old"))
+	assert.Equal(t, symLinkPath+" -> /new (was /old)", downloader.symlinkChange(binaryInfo, "/new"))
+
+	downloader.WithShimTarget("/usr/local/bin/azabox")
+	assert.Equal(t, symLinkPath+" -> /usr/local/bin/azabox (was /old)", downloader.symlinkChange(binaryInfo, "/new"),
+		"links should point to azabox in shim mode")
+}
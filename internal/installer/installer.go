@@ -4,26 +4,41 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
 )
 
 type Installer interface {
-	Install(binaryInfo *dto.BinaryInfo, url string) error
+	Install(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url string) error
+	InstallVersion(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url string) error
+	Uninstall(binaryInfo *dto.BinaryInfo, version string) error
+	IsInstalled(binaryInfo *dto.BinaryInfo, version string) bool
+	Link(binaryInfo *dto.BinaryInfo, version string) error
+	Unlink(binaryInfo *dto.BinaryInfo) error
+	BinaryPath(binaryInfo *dto.BinaryInfo, version string) string
 }
 
 type LocalInstaller struct {
 	tmpFolder     string
 	installFolder string
+	config        config.Config
+	cache         *DownloadCache
+	// shimTarget is the azabox executable the links point to in shim mode
+	shimTarget string
+	// out receives the dry run report of the operations without their own writer
+	out io.Writer
 }
 
 func getUserLocalBinaryFolder() (string, error) {
@@ -51,6 +66,7 @@ func New() (*LocalInstaller, error) {
 	return &LocalInstaller{
 		tmpFolder:     os.TempDir(),
 		installFolder: installFolder,
+		out:           os.Stdout,
 	}, nil
 }
 
@@ -64,51 +80,166 @@ func (l *LocalInstaller) WithTmpFolder(tmpPath string) *LocalInstaller {
 	return l
 }
 
-func (l *LocalInstaller) Install(binaryInfo *dto.BinaryInfo, url string) error {
-	tmpFile, err := l.downloadToTmpDir(binaryInfo, url)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+func (l *LocalInstaller) WithConfig(cfg config.Config) *LocalInstaller {
+	l.config = cfg
+	return l
+}
+
+func (l *LocalInstaller) WithDownloadCache(cache *DownloadCache) *LocalInstaller {
+	l.cache = cache
+	return l
+}
+
+// WithShimTarget links every binary to the given azabox executable which dispatches to the selected version
+func (l *LocalInstaller) WithShimTarget(executable string) *LocalInstaller {
+	l.shimTarget = executable
+	return l
+}
+
+// WithOutput writes the dry run report of link, unlink and uninstall to out
+func (l *LocalInstaller) WithOutput(out io.Writer) *LocalInstaller {
+	l.out = out
+	return l
+}
+
+func (l *LocalInstaller) Install(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url string) error {
+	if DryRun {
+		return l.dryRunInstall(out, binaryInfo, url, true)
 	}
-	targetPath, err := l.installBinary(binaryInfo, tmpFile)
+	versionInfo, err := l.install(ctx, out, binaryInfo, url)
 	if err != nil {
-		return fmt.Errorf("install failed: %w", err)
+		return err
 	}
-	if err := l.createSymlink(binaryInfo, targetPath); err != nil {
+	if err := l.createSymlink(binaryInfo, versionInfo.Path); err != nil {
 		return fmt.Errorf("symlink creation failed: %w", err)
 	}
-	fmt.Println("Installed to " + targetPath)
+	binaryInfo.AddVersion(versionInfo)
+	binaryInfo.ActiveVersion = binaryInfo.InstalledVersion
+	fmt.Fprintln(out, "Installed to "+versionInfo.Path)
 	return nil
 }
 
-func (l *LocalInstaller) downloadToTmpDir(binaryInfo *dto.BinaryInfo, url string) (string, error) {
-	logging.Logger().Debug("Downloading", "url", url, "binary", binaryInfo.Name, "owner",
-		binaryInfo.Owner, "version", binaryInfo.InstalledVersion)
-	fmt.Printf("Downloading %s - %s\n", binaryInfo.FullName, binaryInfo.InstalledVersion)
-
-	resp, err := http.Get(url) //nolint
+// InstallVersion installs and tracks a version like Install but leaves the symlink and the active version
+func (l *LocalInstaller) InstallVersion(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo,
+	url string,
+) error {
+	if DryRun {
+		return l.dryRunInstall(out, binaryInfo, url, false)
+	}
+	versionInfo, err := l.install(ctx, out, binaryInfo, url)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
+	binaryInfo.AddVersion(versionInfo)
+	fmt.Fprintln(out, "Installed to "+versionInfo.Path)
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed: %s", resp.Status)
+func (l *LocalInstaller) install(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url string) (
+	dto.VersionInfo, error,
+) {
+	// every install works in its own folder, concurrent installs never share a file
+	tmpDir, err := os.MkdirTemp(l.tmpFolder, fmt.Sprintf("azabox-%s-", binaryInfo.Name))
+	if err != nil {
+		return dto.VersionInfo{}, fmt.Errorf("download failed: %w", err)
 	}
+	defer os.RemoveAll(tmpDir)
 
-	tmpFileName := fmt.Sprintf("azabox-%s", getFileName(url))
-	tempFile, err := os.Create(filepath.Clean(
-		filepath.Join(l.tmpFolder, tmpFileName)))
+	asset, err := l.fetchVerifiedAsset(ctx, out, binaryInfo, url, tmpDir)
 	if err != nil {
-		return "", err
+		return dto.VersionInfo{}, err
+	}
+	targetPath, err := l.installBinary(binaryInfo, asset.path)
+	if err != nil {
+		return dto.VersionInfo{}, fmt.Errorf("install failed: %w", err)
+	}
+	return dto.VersionInfo{
+		Version:     binaryInfo.InstalledVersion,
+		Path:        targetPath,
+		InstalledAt: time.Now().UTC(),
+		URL:         url,
+		SHA256:      asset.digest,
+		Verified:    asset.verified,
+		Signature:   asset.signature,
+		// the release files are kept as resolved, verified or not, the lockfile downloads them again
+		ChecksumURL:   binaryInfo.ChecksumURL,
+		SignatureURLs: binaryInfo.SignatureURLs,
+		AssetAPIURLs:  binaryInfo.AssetAPIURLs,
+	}, nil
+}
+
+type verifiedAsset struct {
+	path      string
+	digest    string
+	verified  bool
+	signature string
+}
+
+// fetchVerifiedAsset copies the asset from the download cache when it holds it with the verifications
+// currently required, otherwise it downloads and verifies the asset then caches it
+func (l *LocalInstaller) fetchVerifiedAsset(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, url,
+	tmpDir string,
+) (verifiedAsset, error) {
+	if asset, ok := l.cachedAsset(out, binaryInfo, url, tmpDir); ok {
+		return asset, nil
 	}
-	defer tempFile.Close()
 
-	_, err = io.Copy(tempFile, resp.Body)
+	tmpFile, err := l.downloadToTmpDir(ctx, out, binaryInfo, url, tmpDir)
 	if err != nil {
-		return "", err
+		return verifiedAsset{}, fmt.Errorf("download failed: %w", err)
+	}
+	checksum, err := verifyChecksum(ctx, out, binaryInfo, url, tmpFile)
+	if err != nil {
+		return verifiedAsset{}, fmt.Errorf("checksum verification failed: %w", err)
+	}
+	digest, verified := checksum.digest, checksum.verified
+	signature, err := l.verifySignature(ctx, out, binaryInfo, tmpFile, checksum.checksums)
+	if err != nil {
+		return verifiedAsset{}, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	if l.cache != nil {
+		entry := CachedDownload{URL: url, SHA256: digest, Verified: verified, Signature: signature}
+		// the cache only saves a later download, failing to fill it must not fail the install
+		if err := l.cache.Store(entry, tmpFile); err != nil {
+			logging.Logger().Debug("cannot cache download", "url", url, "error", err)
+		}
+	}
+	return verifiedAsset{path: tmpFile, digest: digest, verified: verified, signature: signature}, nil
+}
+
+func (l *LocalInstaller) cachedAsset(out io.Writer, binaryInfo *dto.BinaryInfo, url, tmpDir string) (
+	verifiedAsset, bool,
+) {
+	if l.cache == nil {
+		return verifiedAsset{}, false
+	}
+	entry, ok := l.cache.Lookup(url)
+	if !ok {
+		return verifiedAsset{}, false
+	}
+	if binaryInfo.LockedSHA256 != "" {
+		if entry.SHA256 != binaryInfo.LockedSHA256 {
+			logging.Logger().Debug("cached download does not match the lockfile", "url", url)
+			return verifiedAsset{}, false
+		}
+		entry.Verified = true
+	}
+	if (RequireChecksum && !entry.Verified) ||
+		(l.config.SignaturePolicy(binaryInfo.FullName).Required && entry.Signature == "") {
+		logging.Logger().Debug("cached download does not meet the verification policy", "url", url)
+		return verifiedAsset{}, false
 	}
 
-	return tempFile.Name(), nil
+	tmpFile := filepath.Join(tmpDir, getFileName(url))
+	if err := l.cache.CopyTo(entry, tmpFile); err != nil {
+		logging.Logger().Debug("cannot use cached download", "url", url, "error", err)
+		return verifiedAsset{}, false
+	}
+	fmt.Fprintf(out, "Using cached %s - %s (sha256 %s)\n", binaryInfo.FullName, binaryInfo.InstalledVersion,
+		entry.SHA256)
+	return verifiedAsset{path: tmpFile, digest: entry.SHA256, verified: entry.Verified, signature: entry.Signature},
+		true
 }
 
 func (l *LocalInstaller) installBinary(binaryInfo *dto.BinaryInfo, tmpPath string) (string, error) {
@@ -130,7 +261,7 @@ func (l *LocalInstaller) installBinary(binaryInfo *dto.BinaryInfo, tmpPath strin
 		return "", err
 	}
 
-	targetPath := filepath.Join(l.installFolder, fmt.Sprintf("%s-%s", binaryInfo.Name, binaryInfo.InstalledVersion))
+	targetPath := l.BinaryPath(binaryInfo, binaryInfo.InstalledVersion)
 	out, err := os.Create(filepath.Clean(targetPath))
 	if err != nil {
 		return "", err
@@ -163,7 +294,8 @@ func extractZip(path, binaryName string) (string, error) {
 	}
 	defer r.Close()
 
-	tempDir := os.TempDir()
+	// the binary is extracted next to the archive, in the folder of the install
+	tempDir := filepath.Dir(path)
 	for _, f := range r.File {
 		fileName := f.Name
 		if tmp := strings.Split(fileName, "/"); len(tmp) > 1 {
@@ -205,7 +337,7 @@ func extractTarGz(path, tool string) (string, error) {
 	defer gz.Close()
 
 	tarReader := tar.NewReader(gz)
-	tempDir := os.TempDir()
+	tempDir := filepath.Dir(path)
 	for {
 		hdr, err := tarReader.Next()
 		if err == io.EOF {
@@ -235,9 +367,70 @@ func extractTarGz(path, tool string) (string, error) {
 	return "", fmt.Errorf("no matching binary found in tar.gz")
 }
 
+// BinaryPath returns where a version of the binary is installed
+func (l *LocalInstaller) BinaryPath(binaryInfo *dto.BinaryInfo, version string) string {
+	return filepath.Join(l.installFolder, fmt.Sprintf("%s-%s", binaryInfo.Name, version))
+}
+
+func (l *LocalInstaller) Uninstall(binaryInfo *dto.BinaryInfo, version string) error {
+	targetPath := l.BinaryPath(binaryInfo, version)
+	logging.Logger().Debug("removing binary", "path", targetPath, "binary", binaryInfo.Name,
+		"version", version)
+	if DryRun {
+		fmt.Fprintf(l.out, "Would remove %s\n", targetPath)
+		return nil
+	}
+	if err := os.Remove(filepath.Clean(targetPath)); err != nil {
+		return fmt.Errorf("remove %s failed: %w", targetPath, err)
+	}
+	return nil
+}
+
+func (l *LocalInstaller) IsInstalled(binaryInfo *dto.BinaryInfo, version string) bool {
+	info, err := os.Stat(l.BinaryPath(binaryInfo, version))
+	return err == nil && !info.IsDir()
+}
+
+func (l *LocalInstaller) Link(binaryInfo *dto.BinaryInfo, version string) error {
+	if DryRun {
+		return l.dryRunLink(binaryInfo, version)
+	}
+	targetPath := l.BinaryPath(binaryInfo, version)
+	if _, err := os.Stat(targetPath); err != nil {
+		return fmt.Errorf("version %s of %s is not installed: %w", version, binaryInfo.Name, err)
+	}
+	if err := l.createSymlink(binaryInfo, targetPath); err != nil {
+		return fmt.Errorf("symlink creation failed: %w", err)
+	}
+	return nil
+}
+
+func (l *LocalInstaller) Unlink(binaryInfo *dto.BinaryInfo) error {
+	symLinkPath := filepath.Join(l.installFolder, binaryInfo.Name)
+	info, err := os.Lstat(symLinkPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s is not a symlink, refusing to remove it", symLinkPath)
+	}
+	logging.Logger().Debug("removing symlink", "path", symLinkPath)
+	if DryRun {
+		fmt.Fprintf(l.out, "Would remove symlink %s\n", symLinkPath)
+		return nil
+	}
+	return os.Remove(symLinkPath)
+}
+
 func (l *LocalInstaller) createSymlink(binaryInfo *dto.BinaryInfo, target string) error {
 	symLinkPath := filepath.Join(l.installFolder, binaryInfo.Name)
-	logging.Logger().Debug("creating symlink", "path", symLinkPath)
+	if l.shimTarget != "" {
+		target = l.shimTarget
+	}
+	logging.Logger().Debug("creating symlink", "path", symLinkPath, "target", target)
 	_ = os.Remove(symLinkPath)
 	if err := os.Symlink(target, symLinkPath); err != nil {
 		return err
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -56,10 +57,45 @@ func TestDownloader(t *testing.T) {
 		require.NoError(t, err)
 		downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir)
 
-		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
-		err = downloader.Install(binaryInfo, server.URL+"/foo")
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			AssetAPIURLs: map[string]string{server.URL + "/foo": server.URL + "/assets/1"}}
+		err = downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/foo")
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", binaryInfo.ActiveVersion)
+		versionInfo, ok := binaryInfo.FindVersion("v1.0.0")
+		require.True(t, ok, "installed version should be tracked")
+		assert.Equal(t, filepath.Join(tmpDir, "tool-v1.0.0"), versionInfo.Path)
+		assert.Equal(t, server.URL+"/foo", versionInfo.URL)
+		assert.Equal(t, binaryInfo.AssetAPIURLs, versionInfo.AssetAPIURLs, "release files should be kept to lock them")
+		assert.False(t, versionInfo.InstalledAt.IsZero())
+	})
+
+	t.Run("should install a version without linking it", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.WriteString(w, "binary data")
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir)
+		activeTarget := filepath.Join(tmpDir, "tool-v2.0.0")
+		require.NoError(t, os.Symlink(activeTarget, filepath.Join(tmpDir, "tool")))
+
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0", ActiveVersion: "v2.0.0"}
+		err = downloader.InstallVersion(t.Context(), io.Discard, binaryInfo, server.URL+"/foo")
 
 		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", binaryInfo.ActiveVersion)
+		_, ok := binaryInfo.FindVersion("v1.0.0")
+		assert.True(t, ok, "installed version should be tracked")
+		assert.True(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		got, err := os.Readlink(filepath.Join(tmpDir, "tool"))
+		require.NoError(t, err)
+		assert.Equal(t, activeTarget, got, "symlink should be left untouched")
 	})
 
 	t.Run("should handle download error", func(t *testing.T) {
@@ -72,7 +108,7 @@ func TestDownloader(t *testing.T) {
 		downloader.WithTmpFolder(tmpFolder).WithInstallFolder(tmpFolder)
 		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
 
-		err = downloader.Install(binaryInfo, server.URL)
+		err = downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
@@ -93,10 +129,10 @@ func TestDownloader_DownloadToTmpDir(t *testing.T) {
 		downloader.WithTmpFolder(tmpFolder).WithInstallFolder(tmpFolder)
 
 		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
-		file, err := downloader.downloadToTmpDir(binaryInfo, server.URL+"/foo")
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, server.URL+"/foo", tmpFolder)
 
 		require.NoError(t, err)
-		filePath := filepath.Join(tmpFolder, "azabox-foo")
+		filePath := filepath.Join(tmpFolder, "foo")
 		assert.Equal(t, filePath, file)
 		info, err := os.Stat(filePath)
 		require.NoError(t, err)
@@ -112,7 +148,7 @@ func TestDownloader_DownloadToTmpDir(t *testing.T) {
 		downloader.WithTmpFolder(t.TempDir())
 		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
 
-		file, err := downloader.downloadToTmpDir(binaryInfo, server.URL)
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, server.URL, t.TempDir())
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
@@ -125,12 +161,38 @@ func TestDownloader_DownloadToTmpDir(t *testing.T) {
 		downloader.WithTmpFolder(t.TempDir())
 		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
 
-		file, err := downloader.downloadToTmpDir(binaryInfo, "http://%41:8080/")
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, "http://%41:8080/", t.TempDir())
 
 		assert.Error(t, err)
 		assert.Empty(t, file)
 	})
 
+	t.Run("should remove the partial download when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.WriteString(w, "partial")
+			require.NoError(t, err)
+			w.(http.Flusher).Flush()
+			cancel()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		tmpFolder := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithTmpFolder(tmpFolder)
+		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
+
+		file, err := downloader.downloadToTmpDir(ctx, io.Discard, binaryInfo, server.URL+"/foo", tmpFolder)
+
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, file)
+		entries, err := os.ReadDir(tmpFolder)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
 	t.Run("should handle error on file creation", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -143,10 +205,10 @@ func TestDownloader_DownloadToTmpDir(t *testing.T) {
 		require.NoError(t, err)
 		err = os.Chmod(tmpDir, 0o400)
 		require.NoError(t, err)
-		downloader.WithTmpFolder("/no/existing/folder")
 
 		binaryInfo := &dto.BinaryInfo{Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
-		file, err := downloader.downloadToTmpDir(binaryInfo, server.URL+"/foo")
+		file, err := downloader.downloadToTmpDir(t.Context(), io.Discard, binaryInfo, server.URL+"/foo",
+			"/no/existing/folder")
 
 		assert.Error(t, err)
 		assert.Empty(t, file)
@@ -441,6 +503,128 @@ func TestDownloader_CreateSymlink(t *testing.T) {
 	})
 }
 
+func TestDownloader_Uninstall(t *testing.T) {
+	t.Run("should remove versioned binary", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+		target := filepath.Join(tmpDir, "dummy-v1.0.0")
+		_ = os.WriteFile(target, []byte("binary content"), 0o600)
+
+		err = downloader.Uninstall(binaryInfo, "v1.0.0")
+		require.NoError(t, err)
+
+		_, err = os.Stat(target)
+		assert.ErrorIs(t, err, os.ErrNotExist, "expected binary to be removed")
+	})
+
+	t.Run("should return not exist error when version is not installed", func(t *testing.T) {
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(t.TempDir())
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+
+		err = downloader.Uninstall(binaryInfo, "v1.0.0")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestDownloader_Link(t *testing.T) {
+	t.Run("should point symlink to installed version", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+		target := filepath.Join(tmpDir, "dummy-v1.0.0")
+		_ = os.WriteFile(target, []byte("binary content"), 0o600)
+
+		assert.True(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		err = downloader.Link(binaryInfo, "v1.0.0")
+		require.NoError(t, err)
+
+		got, err := os.Readlink(filepath.Join(tmpDir, binaryInfo.Name))
+		require.NoError(t, err)
+		assert.Equal(t, target, got)
+	})
+
+	t.Run("should point symlink to the shim target", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(tmpDir).WithShimTarget("/usr/local/bin/azabox")
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+		_ = os.WriteFile(filepath.Join(tmpDir, "dummy-v1.0.0"), []byte("binary content"), 0o600)
+
+		err = downloader.Link(binaryInfo, "v1.0.0")
+		require.NoError(t, err)
+
+		got, err := os.Readlink(filepath.Join(tmpDir, binaryInfo.Name))
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/local/bin/azabox", got)
+	})
+
+	t.Run("should handle version not installed", func(t *testing.T) {
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(t.TempDir())
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+
+		assert.False(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		err = downloader.Link(binaryInfo, "v1.0.0")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not installed")
+	})
+}
+
+func TestDownloader_Unlink(t *testing.T) {
+	t.Run("should remove symlink", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(tmpDir)
+		binaryInfo := &dto.BinaryInfo{Name: "dummy"}
+		target := filepath.Join(tmpDir, "dummy-v1.0.0")
+		_ = os.WriteFile(target, []byte("binary content"), 0o600)
+		require.NoError(t, downloader.createSymlink(binaryInfo, target))
+
+		err = downloader.Unlink(binaryInfo)
+		require.NoError(t, err)
+
+		_, err = os.Lstat(filepath.Join(tmpDir, binaryInfo.Name))
+		assert.ErrorIs(t, err, os.ErrNotExist, "expected symlink to be removed")
+		_, err = os.Stat(target)
+		assert.NoError(t, err, "expected target to be kept")
+	})
+
+	t.Run("should ignore missing symlink", func(t *testing.T) {
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(t.TempDir())
+
+		err = downloader.Unlink(&dto.BinaryInfo{Name: "dummy"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should not remove regular file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		downloader, err := New()
+		require.NoError(t, err)
+		downloader.WithInstallFolder(tmpDir)
+		_ = os.WriteFile(filepath.Join(tmpDir, "dummy"), []byte("binary content"), 0o600)
+
+		err = downloader.Unlink(&dto.BinaryInfo{Name: "dummy"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not a symlink")
+	})
+}
+
 func TestIsSupportedFormat(t *testing.T) {
 	t.Run("should return if the format is supported or not", func(t *testing.T) {
 		testCases := []struct {
@@ -0,0 +1,142 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+)
+
+const progressBarWidth = 30
+
+var (
+	// progressRedrawInterval is how often the progress bar is drawn again on a terminal
+	progressRedrawInterval = 100 * time.Millisecond
+	// progressLineInterval is how often a progress line is printed when the output is not a terminal
+	progressLineInterval = 5 * time.Second
+)
+
+// progress reports the progress of a download, as a progress bar redrawn in place on a terminal
+// and as plain lines otherwise
+type progress struct {
+	out        io.Writer
+	prefix     string
+	terminal   bool
+	offset     int64
+	current    int64
+	total      int64
+	start      time.Time
+	lastReport time.Time
+}
+
+// progressOutput sends the download progress to its own writer, the other messages go to the output
+type progressOutput struct {
+	io.Writer
+	progress io.Writer
+	label    string
+}
+
+// WithProgress returns an output whose download progress goes straight to progress labelled with label,
+// e.g. to the terminal while the rest of the output is buffered until the install is over
+func WithProgress(out, progress io.Writer, label string) io.Writer {
+	return &progressOutput{Writer: out, progress: progress, label: label}
+}
+
+// newProgress starts reporting a download resumed at offset, total is -1 when the size is unknown
+func newProgress(out io.Writer, offset, total int64) *progress {
+	prefix := ""
+	if output, ok := out.(*progressOutput); ok {
+		out, prefix = output.progress, output.label+": "
+	}
+	now := time.Now()
+	return &progress{
+		out:        out,
+		prefix:     prefix,
+		terminal:   isTerminal(out),
+		offset:     offset,
+		current:    offset,
+		total:      total,
+		start:      now,
+		lastReport: now,
+	}
+}
+
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *progress) Write(data []byte) (int, error) {
+	p.current += int64(len(data))
+	interval := progressLineInterval
+	if p.terminal {
+		interval = progressRedrawInterval
+	}
+	if time.Since(p.lastReport) >= interval {
+		p.lastReport = time.Now()
+		p.report()
+	}
+	return len(data), nil
+}
+
+// Done reports the end of the download, the progress bar is left on its own line
+func (p *progress) Done(err error) {
+	if p.terminal {
+		p.report()
+		fmt.Fprintln(p.out)
+		return
+	}
+	if err == nil {
+		elapsed := time.Since(p.start)
+		fmt.Fprintf(p.out, "%sDownloaded %s in %s (%s/s)\n", p.prefix, config.FormatSize(p.current),
+			elapsed.Round(time.Second), config.FormatSize(p.rate(elapsed)))
+	}
+}
+
+func (p *progress) report() {
+	elapsed := time.Since(p.start)
+	rate := p.rate(elapsed)
+	if p.terminal {
+		fmt.Fprintf(p.out, "\r%s%s\033[K", p.prefix, p.bar(rate))
+		return
+	}
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "%sDownloaded %s (%s/s)\n", p.prefix, config.FormatSize(p.current),
+			config.FormatSize(rate))
+		return
+	}
+	fmt.Fprintf(p.out, "%sDownloaded %s of %s (%d%%), %s/s, ETA %s\n", p.prefix, config.FormatSize(p.current),
+		config.FormatSize(p.total), p.current*100/p.total, config.FormatSize(rate), p.eta(rate))
+}
+
+func (p *progress) bar(rate int64) string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s  %s/s", config.FormatSize(p.current), config.FormatSize(rate))
+	}
+	filled := int(min(p.current, p.total) * progressBarWidth / p.total)
+	return fmt.Sprintf("[%s%s] %s/%s  %s/s  ETA %s", strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled), config.FormatSize(p.current), config.FormatSize(p.total),
+		config.FormatSize(rate), p.eta(rate))
+}
+
+// rate is the number of bytes downloaded per second since the download started or resumed
+func (p *progress) rate(elapsed time.Duration) int64 {
+	if elapsed < time.Millisecond {
+		return 0
+	}
+	return int64(float64(p.current-p.offset) / elapsed.Seconds())
+}
+
+func (p *progress) eta(rate int64) string {
+	if rate <= 0 || p.current >= p.total {
+		return "-"
+	}
+	return (time.Duration((p.total-p.current)/rate) * time.Second).String()
+}
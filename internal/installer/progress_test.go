@@ -0,0 +1,83 @@
+package installer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	t.Run("should print progress lines", func(t *testing.T) {
+		lineInterval := progressLineInterval
+		progressLineInterval = 0
+		defer func() { progressLineInterval = lineInterval }()
+		var out strings.Builder
+
+		p := newProgress(&out, 0, 4096)
+		_, _ = p.Write(make([]byte, 1024))
+		p.Done(nil)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "Downloaded 1.0KiB of 4.0KiB (25%)")
+		assert.Contains(t, lines[1], "Downloaded 1.0KiB in")
+	})
+
+	t.Run("should print progress lines of unknown size", func(t *testing.T) {
+		lineInterval := progressLineInterval
+		progressLineInterval = 0
+		defer func() { progressLineInterval = lineInterval }()
+		var out strings.Builder
+
+		p := newProgress(&out, 0, -1)
+		_, _ = p.Write(make([]byte, 2048))
+
+		assert.True(t, strings.HasPrefix(out.String(), "Downloaded 2.0KiB ("))
+	})
+
+	t.Run("should only print the end of a quick download", func(t *testing.T) {
+		var out strings.Builder
+
+		p := newProgress(&out, 512, 1024)
+		_, _ = p.Write(make([]byte, 512))
+		p.Done(nil)
+
+		assert.True(t, strings.HasPrefix(out.String(), "Downloaded 1.0KiB in 0s"))
+		assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	})
+
+	t.Run("should not print the end of a failed download", func(t *testing.T) {
+		var out strings.Builder
+
+		p := newProgress(&out, 0, 1024)
+		_, _ = p.Write(make([]byte, 512))
+		p.Done(errors.New("connection reset"))
+
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("should send the progress to its own output", func(t *testing.T) {
+		var out, progressOut strings.Builder
+
+		p := newProgress(WithProgress(&out, &progressOut, "foo"), 512, 1024)
+		_, _ = p.Write(make([]byte, 512))
+		p.Done(nil)
+
+		assert.Empty(t, out.String(), "the buffered output should not get the progress")
+		assert.True(t, strings.HasPrefix(progressOut.String(), "foo: Downloaded 1.0KiB in 0s"))
+	})
+
+	t.Run("should draw a progress bar", func(t *testing.T) {
+		p := &progress{current: 512, total: 1024}
+
+		assert.Equal(t, "[===============               ] 512B/1.0KiB  1.0KiB/s  ETA 0s", p.bar(1024))
+	})
+
+	t.Run("should draw the progress of unknown size", func(t *testing.T) {
+		p := &progress{current: 2048, total: -1}
+
+		assert.Equal(t, "2.0KiB  1.0KiB/s", p.bar(1024))
+	})
+}
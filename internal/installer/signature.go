@@ -0,0 +1,334 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	SignatureCosign   = "cosign"
+	SignatureMinisign = "minisign"
+	SignatureGPG      = "gpg"
+
+	// maxSidecarSize bounds the checksum and signature files published next to an asset
+	maxSidecarSize = 1 << 20
+)
+
+var (
+	ErrSignatureRequired = errors.New("signature required")
+	ErrInvalidSignature  = errors.New("invalid signature")
+)
+
+// cosignBundle covers both the legacy `cosign sign-blob --bundle` format and the sigstore bundle format
+type cosignBundle struct {
+	Base64Signature  string `json:"base64Signature"`
+	MessageSignature struct {
+		Signature string `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+// fetchAsset downloads a checksum or signature file, a file larger than maxSidecarSize is an error
+// rather than a truncated payload
+func fetchAsset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download of %s failed: %s", path.Base(url), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSidecarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSidecarSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", path.Base(url), maxSidecarSize)
+	}
+	return data, nil
+}
+
+// signatureMethod returns the verification method matching a signature file and the configured keys
+func signatureMethod(signatureUrl string, policy config.SignaturePolicy) string {
+	name := path.Base(signatureUrl)
+	switch {
+	case strings.HasSuffix(name, ".minisig"):
+		if policy.MinisignKey != "" {
+			return SignatureMinisign
+		}
+	case strings.HasSuffix(name, ".bundle"), strings.HasSuffix(name, ".sigstore.json"):
+		if policy.CosignKey != "" {
+			return SignatureCosign
+		}
+	case strings.HasSuffix(name, ".asc"), strings.HasSuffix(name, ".gpg"):
+		if policy.GPGKeyring != "" {
+			return SignatureGPG
+		}
+	case strings.HasSuffix(name, ".sig"):
+		// cosign and gpg both publish .sig files, the configured key decides
+		if policy.CosignKey != "" {
+			return SignatureCosign
+		}
+		if policy.GPGKeyring != "" {
+			return SignatureGPG
+		}
+	}
+	return ""
+}
+
+// verifySignature checks the detached signatures published with the asset against the keys pinned
+// for the tool, signatures of the checksum file are checked over the checksums the asset matched,
+// they are skipped when the asset was not verified against a checksum file.
+// It returns the method the asset has been verified with, empty when no signature was checked.
+func (l *LocalInstaller) verifySignature(ctx context.Context, out io.Writer, binaryInfo *dto.BinaryInfo, tmpFile string,
+	checksums []byte,
+) (string, error) {
+	policy := l.config.SignaturePolicy(binaryInfo.FullName)
+	if !policy.HasKey() {
+		if policy.Required {
+			return "", fmt.Errorf("%w for %s but no key is configured", ErrSignatureRequired, binaryInfo.FullName)
+		}
+		return "", nil
+	}
+
+	for _, signatureUrl := range binaryInfo.SignatureURLs {
+		method := signatureMethod(signatureUrl, policy)
+		if method == "" {
+			continue
+		}
+
+		var data []byte
+		var err error
+		signsChecksum := binaryInfo.ChecksumURL != "" &&
+			strings.HasPrefix(path.Base(signatureUrl), path.Base(binaryInfo.ChecksumURL)+".")
+		if signsChecksum {
+			if checksums == nil {
+				continue
+			}
+			data = checksums
+		} else {
+			data, err = os.ReadFile(filepath.Clean(tmpFile))
+		}
+		if err != nil {
+			return "", err
+		}
+
+		signature, err := fetchAsset(ctx, signatureUrl)
+		if err != nil {
+			return "", err
+		}
+
+		logging.Logger().Debug("Verifying signature", "url", signatureUrl, "method", method)
+		if err := verifyWithMethod(method, policy, data, signature); err != nil {
+			return "", fmt.Errorf("%w %s: %w", ErrInvalidSignature, path.Base(signatureUrl), err)
+		}
+		fmt.Fprintf(out, "Signature verified (%s %s)\n", method, path.Base(signatureUrl))
+		return method, nil
+	}
+
+	if policy.Required {
+		return "", fmt.Errorf("%w for %s, no verifiable signature published", ErrSignatureRequired,
+			binaryInfo.FullName)
+	}
+	fmt.Fprintf(out, "No signature published for %s, skipping verification\n", binaryInfo.FullName)
+	return "", nil
+}
+
+func verifyWithMethod(method string, policy config.SignaturePolicy, data, signature []byte) error {
+	switch method {
+	case SignatureCosign:
+		return verifyCosign(policy.CosignKey, data, signature)
+	case SignatureMinisign:
+		return verifyMinisign(policy.MinisignKey, data, signature)
+	case SignatureGPG:
+		return verifyGPG(policy.GPGKeyring, data, signature)
+	}
+	return fmt.Errorf("unsupported signature method %s", method)
+}
+
+func decodeBase64(data []byte) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+}
+
+// cosignSignature extracts the raw signature from a cosign .sig file or bundle
+func cosignSignature(signature []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(signature)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		if decoded, err := decodeBase64(trimmed); err == nil {
+			return decoded, nil
+		}
+		return trimmed, nil
+	}
+
+	var bundle cosignBundle
+	if err := json.Unmarshal(trimmed, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	encoded := bundle.Base64Signature
+	if encoded == "" {
+		encoded = bundle.MessageSignature.Signature
+	}
+	if encoded == "" {
+		return nil, errors.New("no message signature in bundle")
+	}
+	return decodeBase64([]byte(encoded))
+}
+
+func verifyCosign(keyPath string, data, signature []byte) error {
+	rawKey, err := os.ReadFile(filepath.Clean(keyPath))
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(rawKey)
+	if block == nil {
+		return fmt.Errorf("no PEM public key in %s", keyPath)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	sig, err := cosignSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errors.New("ecdsa verification failed")
+		}
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return errors.New("ed25519 verification failed")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
+
+// minisignLines returns the base64 decoded lines of a minisign file, comments excluded
+func minisignLines(data []byte) (payloads [][]byte, trustedComment string, err error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "untrusted comment:"):
+			continue
+		case strings.HasPrefix(line, "trusted comment:"):
+			trustedComment = strings.TrimPrefix(line, "trusted comment: ")
+			continue
+		}
+		payload, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, "", err
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, trustedComment, nil
+}
+
+func minisignPublicKey(key string) ([]byte, error) {
+	if data, err := os.ReadFile(filepath.Clean(key)); err == nil {
+		key = string(data)
+	}
+	payloads, _, err := minisignLines([]byte(key))
+	if err != nil || len(payloads) != 1 || len(payloads[0]) != 42 || string(payloads[0][:2]) != "Ed" {
+		return nil, errors.New("invalid minisign public key")
+	}
+	return payloads[0], nil
+}
+
+func verifyMinisign(key string, data, signature []byte) error {
+	publicKey, err := minisignPublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	payloads, trustedComment, err := minisignLines(signature)
+	if err != nil || len(payloads) != 2 || len(payloads[0]) != 74 || len(payloads[1]) != ed25519.SignatureSize {
+		return errors.New("invalid minisign signature file")
+	}
+	algorithm, keyID, sig := string(payloads[0][:2]), payloads[0][2:10], payloads[0][10:]
+	if !bytes.Equal(keyID, publicKey[2:10]) {
+		return errors.New("signature made with another key")
+	}
+
+	pk := ed25519.PublicKey(publicKey[10:])
+	message := data
+	switch algorithm {
+	case "Ed":
+	case "ED":
+		prehashed := blake2b.Sum512(data)
+		message = prehashed[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %s", algorithm)
+	}
+	if !ed25519.Verify(pk, message, sig) {
+		return errors.New("ed25519 verification failed")
+	}
+	if !ed25519.Verify(pk, slices.Concat(sig, []byte(trustedComment)), payloads[1]) {
+		return errors.New("trusted comment verification failed")
+	}
+	return nil
+}
+
+func verifyGPG(keyring string, data, signature []byte) error {
+	keyring, err := filepath.Abs(keyring)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "azabox-gpg")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	signaturePath := filepath.Join(tmpDir, "asset.sig")
+	dataPath := filepath.Join(tmpDir, "asset")
+	if err := os.WriteFile(signaturePath, signature, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dataPath, data, 0o600); err != nil {
+		return err
+	}
+
+	output, err := exec.Command("gpgv", "--keyring", keyring, signaturePath, dataPath).CombinedOutput() //nolint
+	if err != nil {
+		return fmt.Errorf("gpgv failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package installer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/config"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"golang.org/x/crypto/blake2b"
+)
+
+func newAssetsTestServer(assets map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+}
+
+func newCosignKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	return privateKey, keyPath
+}
+
+func cosignSign(t *testing.T, privateKey *ecdsa.PrivateKey, data string) string {
+	digest := sha256.Sum256([]byte(data))
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func newMinisignKey(t *testing.T) (ed25519.PrivateKey, []byte, string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte("azabox42")
+	encoded := base64.StdEncoding.EncodeToString(slices.Concat([]byte("Ed"), keyID, publicKey))
+	return privateKey, keyID, "untrusted comment: minisign public key\n" + encoded + "\n"
+}
+
+func minisignSign(privateKey ed25519.PrivateKey, keyID []byte, algorithm, data string) string {
+	message := []byte(data)
+	if algorithm == "ED" {
+		prehashed := blake2b.Sum512(message)
+		message = prehashed[:]
+	}
+	sig := ed25519.Sign(privateKey, message)
+	trustedComment := "timestamp:1700000000"
+	globalSig := ed25519.Sign(privateKey, slices.Concat(sig, []byte(trustedComment)))
+	return fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(slices.Concat([]byte(algorithm), keyID, sig)),
+		trustedComment, base64.StdEncoding.EncodeToString(globalSig))
+}
+
+func newSignatureTestInstaller(t *testing.T, policy config.SignaturePolicy) (*LocalInstaller, string) {
+	tmpDir := t.TempDir()
+	downloader, err := New()
+	require.NoError(t, err)
+	downloader.WithTmpFolder(tmpDir).WithInstallFolder(tmpDir).WithConfig(config.Config{
+		Tools: map[string]config.ToolConfig{"user/tool": {Signature: policy}},
+	})
+	tmpFile := filepath.Join(tmpDir, "azabox-tool")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(testBinaryData), 0o600))
+	return downloader, tmpFile
+}
+
+func TestVerifySignature(t *testing.T) {
+	logging.UseInMemoryLogger()
+	cosignKey, cosignKeyPath := newCosignKey(t)
+	minisignKey, keyID, minisignPublicKey := newMinisignKey(t)
+
+	testCases := []struct {
+		name      string
+		policy    config.SignaturePolicy
+		file      string
+		signature string
+		expected  string
+	}{
+		{
+			name:      "cosign signature",
+			policy:    config.SignaturePolicy{CosignKey: cosignKeyPath},
+			file:      "/tool.sig",
+			signature: cosignSign(t, cosignKey, testBinaryData),
+			expected:  SignatureCosign,
+		},
+		{
+			name:      "cosign legacy bundle",
+			policy:    config.SignaturePolicy{CosignKey: cosignKeyPath},
+			file:      "/tool.bundle",
+			signature: fmt.Sprintf(`{"base64Signature": "%s"}`, cosignSign(t, cosignKey, testBinaryData)),
+			expected:  SignatureCosign,
+		},
+		{
+			name:   "sigstore bundle",
+			policy: config.SignaturePolicy{CosignKey: cosignKeyPath},
+			file:   "/tool.sigstore.json",
+			signature: fmt.Sprintf(`{"messageSignature": {"signature": "%s"}}`,
+				cosignSign(t, cosignKey, testBinaryData)),
+			expected: SignatureCosign,
+		},
+		{
+			name:      "minisign prehashed signature",
+			policy:    config.SignaturePolicy{MinisignKey: minisignPublicKey},
+			file:      "/tool.minisig",
+			signature: minisignSign(minisignKey, keyID, "ED", testBinaryData),
+			expected:  SignatureMinisign,
+		},
+		{
+			name:      "minisign legacy signature",
+			policy:    config.SignaturePolicy{MinisignKey: minisignPublicKey},
+			file:      "/tool.minisig",
+			signature: minisignSign(minisignKey, keyID, "Ed", testBinaryData),
+			expected:  SignatureMinisign,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newAssetsTestServer(map[string]string{tc.file: tc.signature})
+			defer server.Close()
+			downloader, tmpFile := newSignatureTestInstaller(t, tc.policy)
+			binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{server.URL + tc.file}}
+
+			method, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, method)
+		})
+	}
+
+	t.Run("should fail on tampered asset", func(t *testing.T) {
+		for _, tc := range testCases {
+			var tampered string
+			if tc.expected == SignatureCosign {
+				tampered = cosignSign(t, cosignKey, "tampered")
+			} else {
+				tampered = minisignSign(minisignKey, keyID, "ED", "tampered")
+			}
+			server := newAssetsTestServer(map[string]string{tc.file: tampered})
+			downloader, tmpFile := newSignatureTestInstaller(t, tc.policy)
+			binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{server.URL + tc.file}}
+
+			_, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+			server.Close()
+
+			require.Error(t, err, tc.name)
+			assert.ErrorIs(t, err, ErrInvalidSignature, tc.name)
+		}
+	})
+
+	t.Run("should verify signed checksum file only when checksum matched", func(t *testing.T) {
+		checksums := testDigest(testBinaryData) + "  tool\n"
+		server := newAssetsTestServer(map[string]string{
+			"/checksums.txt.sig": cosignSign(t, cosignKey, checksums),
+		})
+		defer server.Close()
+		downloader, tmpFile := newSignatureTestInstaller(t, config.SignaturePolicy{
+			Required: true, CosignKey: cosignKeyPath,
+		})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", ChecksumURL: server.URL + "/checksums.txt",
+			SignatureURLs: []string{server.URL + "/checksums.txt.sig"}}
+
+		method, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, []byte(checksums))
+		require.NoError(t, err)
+		assert.Equal(t, SignatureCosign, method)
+
+		_, err = downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrSignatureRequired)
+	})
+
+	t.Run("should verify the signature over the checksums the asset matched", func(t *testing.T) {
+		genuine := testDigest("genuine") + "  tool\n"
+		server := newAssetsTestServer(map[string]string{
+			"/checksums.txt":     genuine,
+			"/checksums.txt.sig": cosignSign(t, cosignKey, genuine),
+		})
+		defer server.Close()
+		downloader, tmpFile := newSignatureTestInstaller(t, config.SignaturePolicy{CosignKey: cosignKeyPath})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", ChecksumURL: server.URL + "/checksums.txt",
+			SignatureURLs: []string{server.URL + "/checksums.txt.sig"}}
+		forged := []byte(testDigest(testBinaryData) + "  tool\n")
+
+		_, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, forged)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("should refuse oversized signature", func(t *testing.T) {
+		server := newAssetsTestServer(map[string]string{"/tool.sig": strings.Repeat("a", maxSidecarSize+1)})
+		defer server.Close()
+		downloader, tmpFile := newSignatureTestInstaller(t, config.SignaturePolicy{CosignKey: cosignKeyPath})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{server.URL + "/tool.sig"}}
+
+		_, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds")
+	})
+
+	t.Run("should skip verification without policy", func(t *testing.T) {
+		downloader, tmpFile := newSignatureTestInstaller(t, config.SignaturePolicy{})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{"https://foo.bar/tool.sig"}}
+
+		method, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, method)
+	})
+
+	t.Run("should fail closed when signature is required", func(t *testing.T) {
+		policies := []config.SignaturePolicy{
+			{Required: true},
+			{Required: true, CosignKey: cosignKeyPath},
+			{Required: true, MinisignKey: minisignPublicKey},
+		}
+		for _, policy := range policies {
+			downloader, tmpFile := newSignatureTestInstaller(t, policy)
+			binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{"https://foo.bar/tool.asc"}}
+
+			_, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrSignatureRequired)
+		}
+	})
+
+	t.Run("should reject signature made with another key", func(t *testing.T) {
+		otherKey, _, _ := newMinisignKey(t)
+		server := newAssetsTestServer(map[string]string{
+			"/tool.minisig": minisignSign(otherKey, []byte("otherkey"), "ED", testBinaryData),
+		})
+		defer server.Close()
+		downloader, tmpFile := newSignatureTestInstaller(t, config.SignaturePolicy{MinisignKey: minisignPublicKey})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", SignatureURLs: []string{server.URL + "/tool.minisig"}}
+
+		_, err := downloader.verifySignature(t.Context(), io.Discard, binaryInfo, tmpFile, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "another key")
+	})
+}
+
+func TestVerifyGPG(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not available")
+	}
+	if _, err := exec.LookPath("gpgv"); err != nil {
+		t.Skip("gpgv is not available")
+	}
+
+	gnupgHome := t.TempDir()
+	t.Setenv("GNUPGHOME", gnupgHome)
+	dataPath := filepath.Join(gnupgHome, "tool")
+	keyringPath := filepath.Join(gnupgHome, "tool.gpg")
+	require.NoError(t, os.WriteFile(dataPath, []byte(testBinaryData), 0o600))
+	for _, args := range [][]string{
+		{"--batch", "--passphrase", "", "--quick-gen-key", "azabox test", "ed25519", "sign", "never"},
+		{"--batch", "--output", keyringPath, "--export", "azabox test"},
+		{"--batch", "--detach-sign", "--output", dataPath + ".sig", dataPath},
+	} {
+		output, err := exec.Command("gpg", args...).CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	signature, err := os.ReadFile(dataPath + ".sig")
+	require.NoError(t, err)
+
+	t.Run("should verify gpg signature", func(t *testing.T) {
+		require.NoError(t, verifyGPG(keyringPath, []byte(testBinaryData), signature))
+	})
+
+	t.Run("should fail on tampered asset", func(t *testing.T) {
+		err := verifyGPG(keyringPath, []byte("tampered"), signature)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "gpgv failed")
+	})
+}
+
+func TestInstallWithSignature(t *testing.T) {
+	t.Run("should record signature method", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		cosignKey, cosignKeyPath := newCosignKey(t)
+		server := newAssetsTestServer(map[string]string{
+			"/tool":     testBinaryData,
+			"/tool.sig": cosignSign(t, cosignKey, testBinaryData),
+		})
+		defer server.Close()
+		downloader, _ := newSignatureTestInstaller(t, config.SignaturePolicy{Required: true, CosignKey: cosignKeyPath})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			SignatureURLs: []string{server.URL + "/tool.sig"}}
+
+		err := downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.NoError(t, err)
+		versionInfo, ok := binaryInfo.FindVersion("v1.0.0")
+		require.True(t, ok)
+		assert.Equal(t, SignatureCosign, versionInfo.Signature)
+	})
+
+	t.Run("should verify a signed checksum file on a locked install", func(t *testing.T) {
+		cosignKey, cosignKeyPath := newCosignKey(t)
+		checksums := testDigest(testBinaryData) + "  tool\n"
+		server := newAssetsTestServer(map[string]string{
+			"/tool":              testBinaryData,
+			"/checksums.txt":     checksums,
+			"/checksums.txt.sig": cosignSign(t, cosignKey, checksums),
+		})
+		defer server.Close()
+		downloader, _ := newSignatureTestInstaller(t, config.SignaturePolicy{Required: true, CosignKey: cosignKeyPath})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", Name: "tool", Owner: "user", InstalledVersion: "v1.0.0",
+			LockedSHA256: testDigest(testBinaryData), ChecksumURL: server.URL + "/checksums.txt",
+			SignatureURLs: []string{server.URL + "/checksums.txt.sig"}}
+
+		err := downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.NoError(t, err)
+		versionInfo, ok := binaryInfo.FindVersion("v1.0.0")
+		require.True(t, ok)
+		assert.True(t, versionInfo.Verified)
+		assert.Equal(t, SignatureCosign, versionInfo.Signature)
+	})
+
+	t.Run("should not install without required signature", func(t *testing.T) {
+		server := newAssetsTestServer(map[string]string{"/tool": testBinaryData})
+		defer server.Close()
+		downloader, _ := newSignatureTestInstaller(t, config.SignaturePolicy{Required: true})
+		binaryInfo := &dto.BinaryInfo{FullName: "user/tool", Name: "tool", Owner: "user", InstalledVersion: "v1.0.0"}
+
+		err := downloader.Install(t.Context(), io.Discard, binaryInfo, server.URL+"/tool")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrSignatureRequired)
+		assert.False(t, downloader.IsInstalled(binaryInfo, "v1.0.0"))
+		assert.Empty(t, binaryInfo.Versions)
+	})
+}
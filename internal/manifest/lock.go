@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LockFileName = "azabox.lock"
+
+	lockHeader = "# generated by azabox sync, install it with azabox install --frozen\n"
+)
+
+// LockedTool is a binary as installed by sync, with everything needed to download it again
+type LockedTool struct {
+	// Name is the full name of the binary, "<owner>/<binary>"
+	Name     string `yaml:"name"`
+	Version  string `yaml:"version"`
+	Resolver string `yaml:"resolver"`
+	URL      string `yaml:"url"`
+	SHA256   string `yaml:"sha256,omitempty"`
+	// Constraint, URLTemplate, TemplateVars and LatestVersionURL are how the binary is resolved,
+	// a frozen install records them so that update keeps resolving it the same way
+	Constraint       string            `yaml:"constraint,omitempty"`
+	URLTemplate      string            `yaml:"url_template,omitempty"`
+	TemplateVars     map[string]string `yaml:"template_vars,omitempty"`
+	LatestVersionURL string            `yaml:"latest_version_url,omitempty"`
+	// ChecksumURL and SignatureURLs are the files the asset is verified with
+	ChecksumURL   string   `yaml:"checksum_url,omitempty"`
+	SignatureURLs []string `yaml:"signature_urls,omitempty"`
+	// AssetAPIURLs maps the release files to the API endpoint serving them, for private repositories
+	AssetAPIURLs map[string]string `yaml:"asset_api_urls,omitempty"`
+}
+
+// Equal reports whether both tools are locked the same way
+func (t LockedTool) Equal(other LockedTool) bool {
+	return t.Name == other.Name && t.Version == other.Version && t.Resolver == other.Resolver &&
+		t.URL == other.URL && t.SHA256 == other.SHA256 && t.Constraint == other.Constraint &&
+		t.URLTemplate == other.URLTemplate && maps.Equal(t.TemplateVars, other.TemplateVars) &&
+		t.LatestVersionURL == other.LatestVersionURL && t.ChecksumURL == other.ChecksumURL &&
+		slices.Equal(t.SignatureURLs, other.SignatureURLs) && maps.Equal(t.AssetAPIURLs, other.AssetAPIURLs)
+}
+
+// Lock records the exact binaries installed for a manifest
+type Lock struct {
+	Tools []LockedTool `yaml:"tools"`
+}
+
+// LoadLock reads the lockfile
+func LoadLock(path string) (Lock, error) {
+	var lock Lock
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return lock, err
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	for i, tool := range lock.Tools {
+		if tool.Name == "" || tool.Version == "" || tool.URL == "" {
+			return lock, fmt.Errorf("invalid lockfile %s: tool %d needs a name, a version and an url", path, i+1)
+		}
+	}
+	return lock, nil
+}
+
+// Save writes the lockfile, the tools are sorted by name so it diffs nicely
+func (l Lock) Save(path string) error {
+	l.Tools = slices.Clone(l.Tools)
+	slices.SortFunc(l.Tools, func(a, b LockedTool) int { return strings.Compare(a.Name, b.Name) })
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Clean(path), append([]byte(lockHeader), data...), 0o644)
+}
+
+// Find returns the locked tool of the binary
+func (l Lock) Find(fullName string) (LockedTool, bool) {
+	idx := slices.IndexFunc(l.Tools, func(tool LockedTool) bool { return tool.Name == fullName })
+	if idx < 0 {
+		return LockedTool{}, false
+	}
+	return l.Tools[idx], true
+}
@@ -0,0 +1,96 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	t.Run("should save and load tools sorted by name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), LockFileName)
+		lock := Lock{Tools: []LockedTool{
+			{
+				Name:             "kubectl/kubectl",
+				Version:          "v1.31.0",
+				Resolver:         "url",
+				URL:              "https://foo.bar/v1.31.0/kubectl",
+				URLTemplate:      "https://foo.bar/{{.Version}}/kubectl",
+				TemplateVars:     map[string]string{"Flavor": "static"},
+				LatestVersionURL: "https://foo.bar/stable.txt",
+			},
+			{
+				Name:          "helmfile/helmfile",
+				Version:       "v1.1.3",
+				Resolver:      "github",
+				URL:           "https://foo.bar/helmfile.tar.gz",
+				SHA256:        strings.Repeat("a", 64),
+				Constraint:    "~1.1",
+				ChecksumURL:   "https://foo.bar/checksums.txt",
+				SignatureURLs: []string{"https://foo.bar/checksums.txt.sig"},
+				AssetAPIURLs:  map[string]string{"https://foo.bar/helmfile.tar.gz": "https://api.foo.bar/assets/1"},
+			},
+		}}
+
+		require.NoError(t, lock.Save(path))
+		loaded, err := LoadLock(path)
+
+		require.NoError(t, err)
+		require.Len(t, loaded.Tools, 2)
+		assert.Equal(t, lock.Tools[1], loaded.Tools[0])
+		assert.Equal(t, lock.Tools[0], loaded.Tools[1])
+		assert.Equal(t, "kubectl/kubectl", lock.Tools[0].Name, "saving should not reorder the caller tools")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), lockHeader))
+	})
+
+	t.Run("should compare locked tools", func(t *testing.T) {
+		tool := LockedTool{
+			Name:          "helmfile/helmfile",
+			Version:       "v1.1.3",
+			URL:           "https://foo.bar/helmfile.tar.gz",
+			SignatureURLs: []string{"https://foo.bar/helmfile.tar.gz.sig"},
+			AssetAPIURLs:  map[string]string{"https://foo.bar/helmfile.tar.gz": "https://api.foo.bar/assets/1"},
+		}
+		other := tool
+		other.AssetAPIURLs = map[string]string{"https://foo.bar/helmfile.tar.gz": "https://api.foo.bar/assets/2"}
+
+		assert.True(t, tool.Equal(tool))
+		assert.False(t, tool.Equal(other))
+		other = tool
+		other.TemplateVars = map[string]string{"Flavor": "static"}
+		assert.False(t, tool.Equal(other))
+		assert.True(t, LockedTool{}.Equal(LockedTool{SignatureURLs: []string{}}), "empty files should be equal")
+	})
+
+	t.Run("should find a tool", func(t *testing.T) {
+		lock := Lock{Tools: []LockedTool{{Name: "helmfile/helmfile", Version: "v1.1.3"}}}
+
+		tool, ok := lock.Find("helmfile/helmfile")
+		assert.True(t, ok)
+		assert.Equal(t, "v1.1.3", tool.Version)
+		_, ok = lock.Find("stern/stern")
+		assert.False(t, ok)
+	})
+
+	t.Run("should handle missing file", func(t *testing.T) {
+		_, err := LoadLock(filepath.Join(t.TempDir(), LockFileName))
+
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("should handle incomplete tool", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), LockFileName)
+		require.NoError(t, os.WriteFile(path, []byte("tools:\n  - name: helmfile/helmfile\n"), 0o600))
+
+		_, err := LoadLock(path)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "needs a name, a version and an url")
+	})
+}
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/semver"
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = "azabox.yaml"
+
+// Tool is a binary listed in the manifest, its fields match the install command flags
+type Tool struct {
+	// Name is the binary as given to install, "<binary>" or "<project>/<binary>"
+	Name string `yaml:"name"`
+	// Version is an exact tag or a constraint (e.g. "~1.2"), latest when empty
+	Version string `yaml:"version"`
+	// Source only resolves the binary from this resolver (github, gitlab, url)
+	Source string `yaml:"source"`
+	// URL, LatestURL and Vars install the binary from an url template
+	URL       string            `yaml:"url"`
+	LatestURL string            `yaml:"latest-url"`
+	Vars      map[string]string `yaml:"vars"`
+}
+
+// Manifest lists the binaries a user or a team wants installed
+type Manifest struct {
+	Tools []Tool `yaml:"tools"`
+}
+
+// Load reads and validates the manifest
+func Load(path string) (Manifest, error) {
+	var manifest Manifest
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return manifest, err
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if err := manifest.validate(); err != nil {
+		return manifest, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+func (m Manifest) validate() error {
+	names := make(map[string]bool, len(m.Tools))
+	for i, tool := range m.Tools {
+		if tool.Name == "" {
+			return fmt.Errorf("tool %d has no name", i+1)
+		}
+		name := dto.NormalizeName(tool.Name)
+		if names[name] {
+			return fmt.Errorf("tool %s is listed twice", tool.Name)
+		}
+		names[name] = true
+
+		if tool.URL == "" && tool.LatestURL != "" {
+			return fmt.Errorf("tool %s: latest-url can only be used with url", tool.Name)
+		}
+		if semver.IsConstraint(tool.Version) {
+			if _, err := semver.ParseConstraint(tool.Version); err != nil {
+				return fmt.Errorf("tool %s: %w", tool.Name, err)
+			}
+		}
+	}
+	return nil
+}
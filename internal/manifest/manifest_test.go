@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should load tools", func(t *testing.T) {
+		path := writeManifest(t, `tools:
+  - name: helmfile
+    version: ~1.1
+  - name: norwoodj/helm-docs
+    version: v1.14.2
+    source: github
+  - name: kubectl
+    url: https://dl.k8s.io/release/{{.Version}}/bin/{{.OS}}/{{.Arch}}/kubectl
+    latest-url: https://dl.k8s.io/release/stable.txt
+    vars:
+      channel: stable
+`)
+
+		manifest, err := Load(path)
+
+		require.NoError(t, err)
+		require.Len(t, manifest.Tools, 3)
+		assert.Equal(t, Tool{Name: "helmfile", Version: "~1.1"}, manifest.Tools[0])
+		assert.Equal(t, Tool{Name: "norwoodj/helm-docs", Version: "v1.14.2", Source: "github"}, manifest.Tools[1])
+		assert.Equal(t, "https://dl.k8s.io/release/stable.txt", manifest.Tools[2].LatestURL)
+		assert.Equal(t, map[string]string{"channel": "stable"}, manifest.Tools[2].Vars)
+	})
+
+	t.Run("should handle missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), FileName))
+
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "should handle invalid yaml", content: "tools: [", expected: "invalid manifest"},
+		{name: "should handle tool without name", content: "tools:\n  - version: v1.0.0\n", expected: "has no name"},
+		{
+			name:     "should handle duplicated tool",
+			content:  "tools:\n  - name: helmfile\n  - name: helmfile/helmfile\n",
+			expected: "listed twice",
+		},
+		{
+			name:     "should handle latest-url without url",
+			content:  "tools:\n  - name: kubectl\n    latest-url: https://dl.k8s.io/release/stable.txt\n",
+			expected: "latest-url can only be used with url",
+		},
+		{
+			name:     "should handle invalid constraint",
+			content:  "tools:\n  - name: helmfile\n    version: '>=foo'\n",
+			expected: "tool helmfile",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeManifest(t, tc.content))
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
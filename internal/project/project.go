@@ -0,0 +1,71 @@
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+)
+
+// FileName is the project file pinning binary versions for its directory tree
+const FileName = ".azabox-versions"
+
+// Versions are the versions pinned by a project file, Path is empty when no file was found
+type Versions struct {
+	Path     string
+	versions map[string]string
+}
+
+// Find looks for the project file in dir then in each of its parents
+func Find(dir string) (Versions, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Versions{}, err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		versions, err := Load(path)
+		if !errors.Is(err, os.ErrNotExist) {
+			return versions, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Versions{}, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a project file, one "<binary> <version>" per line, "#" starts a comment
+func Load(path string) (Versions, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return Versions{}, err
+	}
+	defer file.Close()
+
+	versions := Versions{Path: path, versions: make(map[string]string)}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return Versions{}, fmt.Errorf("invalid %s line %d: expected \"<binary> <version>\"", path, lineNumber)
+		}
+		versions.versions[dto.NormalizeName(fields[0])] = fields[1]
+	}
+	return versions, scanner.Err()
+}
+
+// Version returns the version pinned for the binary
+func (v Versions) Version(fullName string) (string, bool) {
+	version, ok := v.versions[fullName]
+	return version, ok
+}
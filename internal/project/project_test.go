@@ -0,0 +1,65 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	t.Run("should find the project file in a parent directory", func(t *testing.T) {
+		root := t.TempDir()
+		content := "# versions of the project\nhelm 3.14.2\nkubernetes/kubectl v1.30.1 # cluster version\n\n"
+		require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte(content), 0o600))
+		dir := filepath.Join(root, "charts", "app")
+		require.NoError(t, os.MkdirAll(dir, 0o750))
+
+		versions, err := Find(dir)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, FileName), versions.Path)
+		version, ok := versions.Version("helm/helm")
+		assert.True(t, ok, "short name should be normalized")
+		assert.Equal(t, "3.14.2", version)
+		version, ok = versions.Version("kubernetes/kubectl")
+		assert.True(t, ok)
+		assert.Equal(t, "v1.30.1", version)
+		_, ok = versions.Version("stern/stern")
+		assert.False(t, ok)
+	})
+
+	t.Run("should use the closest project file", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte("helm 3.14.2\n"), 0o600))
+		dir := filepath.Join(root, "legacy")
+		require.NoError(t, os.MkdirAll(dir, 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("helm 2.17.0\n"), 0o600))
+
+		versions, err := Find(dir)
+
+		require.NoError(t, err)
+		version, _ := versions.Version("helm/helm")
+		assert.Equal(t, "2.17.0", version)
+	})
+
+	t.Run("should handle directory tree without project file", func(t *testing.T) {
+		versions, err := Find(t.TempDir())
+
+		require.NoError(t, err)
+		_, ok := versions.Version("helm/helm")
+		assert.False(t, ok)
+	})
+
+	t.Run("should handle invalid line", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("helm\n"), 0o600))
+
+		_, err := Find(dir)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 1")
+	})
+}
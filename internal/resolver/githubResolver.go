@@ -1,23 +1,26 @@
 package resolver
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"runtime"
+	"slices"
 	"strings"
 
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
-	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
-	"gitlab.com/ludovic-alarcon/azabox/internal/platform"
 )
 
 const (
 	GHBaseAPIUrl                      = "https://api.github.com"
+	GHHost                            = "github.com"
 	GHAPIRepoSegment                  = "/repos"
 	GHAPIReleaseSegmentTemplate       = "/%s/releases/tags/%s"
 	GHAPIReleaseLatestSegmentTemplate = "/%s/releases/%s"
+	GHAPIReleasesSegmentTemplate      = "/%s/releases?per_page=%d&page=%d"
 	GithubResolverName                = "github"
 
 	AcceptHeader    = "application/vnd.github+json"
@@ -33,11 +36,23 @@ type GithubResolver struct {
 
 type GitHubReleaseResponseAsset struct {
 	Url string `json:"browser_download_url"`
+	// APIUrl is the asset endpoint, used to download from private repositories
+	APIUrl string `json:"url"`
 }
 
 type GitHubReleaseResponse struct {
-	Name   string                       `json:"name"`
-	Assets []GitHubReleaseResponseAsset `json:"assets"`
+	Name    string                       `json:"name"`
+	TagName string                       `json:"tag_name"`
+	Draft   bool                         `json:"draft"`
+	Assets  []GitHubReleaseResponseAsset `json:"assets"`
+}
+
+// version returns the release name, the version azabox tracks, or its tag when the release has no name
+func (r GitHubReleaseResponse) version() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.TagName
 }
 
 func NewGithubResolver(baseAPIUrl string) *GithubResolver {
@@ -49,78 +64,124 @@ func NewGithubResolver(baseAPIUrl string) *GithubResolver {
 	}
 }
 
-func createHttpRequest(url string) *http.Request {
-	req, _ := http.NewRequest("GET", url, nil)
+func createHttpRequest(ctx context.Context, url string) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Accept", AcceptHeader)
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 	req.Header.Set("User-Agent", UserAgentHeader)
+	if token := httpclient.GitHubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	return req
 }
 
-func (r GithubResolver) callGithubReleaseEndpoint(binaryInfo dto.BinaryInfo) (GitHubReleaseResponse, error) {
+func (r GithubResolver) callGithubReleaseEndpoint(ctx context.Context, binaryInfo dto.BinaryInfo) (
+	GitHubReleaseResponse, error,
+) {
 	url := fmt.Sprintf(r.releaseAPIUrlTemplate, binaryInfo.FullName, binaryInfo.Version)
 	if binaryInfo.Version == "latest" {
 		url = fmt.Sprintf(r.releaseLatestAPIUrlTemplate, binaryInfo.FullName, binaryInfo.Version)
 	}
-	req := createHttpRequest(url)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return GitHubReleaseResponse{}, err
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return GitHubReleaseResponse{}, fmt.Errorf("request error: %s", resp.Status)
-	}
-
 	var data GitHubReleaseResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return GitHubReleaseResponse{}, fmt.Errorf("failed to parse data: %w", err)
+	if err := getJSON(createHttpRequest(ctx, url), &data); err != nil {
+		return GitHubReleaseResponse{}, err
 	}
 
 	return data, nil
 }
 
-func (r GithubResolver) Resolve(binaryInfo *dto.BinaryInfo) (string, error) {
+func (r GithubResolver) Resolve(ctx context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
 	logging.Logger().Debug("Resolve binary for github", "binary", binaryInfo.Name, "owner",
 		binaryInfo.Owner, "version", binaryInfo.Version)
 
-	data, err := r.callGithubReleaseEndpoint(*binaryInfo)
+	data, err := r.callGithubReleaseEndpoint(ctx, *binaryInfo)
 	if err != nil {
 		return "", err
 	}
 
-	os := runtime.GOOS
-	arch := runtime.GOARCH
-	archNormalized := platform.NormalizeArch(arch)
-	logging.Logger().Debug("os info", "os", os, "arch", arch, "normalized", archNormalized)
+	authenticated := httpclient.GitHubToken() != ""
+	assetUrls := make([]string, 0, len(data.Assets))
+	for _, asset := range data.Assets {
+		assetUrls = append(assetUrls, asset.Url)
+		// checksums and signatures are downloaded as well, every asset goes through the API
+		if authenticated && asset.APIUrl != "" {
+			httpclient.RedirectAsset(asset.Url, asset.APIUrl)
+		}
+	}
 
 	for _, asset := range data.Assets {
-		downloadURL := strings.ToLower(asset.Url)
-		if strings.Contains(downloadURL, os) &&
-			(strings.Contains(downloadURL, arch) || strings.Contains(downloadURL, archNormalized)) {
-			if installer.IsSupportedFormat(downloadURL) {
-				logging.Logger().Debug("download URL", "url", asset.Url, "name", binaryInfo.Name,
-					"platform", os, "arch", arch, "version", binaryInfo.Version, "resolvedVersion", data.Name)
-				binaryInfo.InstalledVersion = data.Name
-				binaryInfo.Resolver = GithubResolverName
-				return asset.Url, nil
-			}
+		if matchPlatform(asset.Url) {
+			logging.Logger().Debug("download URL", "url", asset.Url, "name", binaryInfo.Name,
+				"platform", runtime.GOOS, "arch", runtime.GOARCH, "version", binaryInfo.Version,
+				"resolvedVersion", data.version())
+			binaryInfo.InstalledVersion = data.version()
+			binaryInfo.Resolver = GithubResolverName
+			binaryInfo.ChecksumURL = checksumAsset(asset.Url, assetUrls)
+			binaryInfo.SignatureURLs = signatureAssets(asset.Url, binaryInfo.ChecksumURL, assetUrls)
+			binaryInfo.AssetAPIURLs = assetAPIUrls(data.Assets,
+				append([]string{asset.Url, binaryInfo.ChecksumURL}, binaryInfo.SignatureURLs...))
+			return asset.Url, nil
 		}
 	}
 
 	return "", nil
 }
 
-func (r GithubResolver) ResolveLatestVersion(binaryInfo dto.BinaryInfo) (string, error) {
+// IsGitHubAssetAPIUrl reports whether apiUrl is the GitHub API endpoint of a github.com release file,
+// the only endpoints the token may be sent to on download
+func IsGitHubAssetAPIUrl(downloadUrl, apiUrl string) bool {
+	download, err := url.Parse(downloadUrl)
+	if err != nil {
+		return false
+	}
+	api, err := url.Parse(apiUrl)
+	if err != nil {
+		return false
+	}
+	return download.Scheme == "https" && download.Host == GHHost &&
+		api.Scheme+"://"+api.Host == GHBaseAPIUrl && strings.HasPrefix(api.Path, GHAPIRepoSegment+"/")
+}
+
+// assetAPIUrls maps the given release files to their API endpoint
+func assetAPIUrls(assets []GitHubReleaseResponseAsset, urls []string) map[string]string {
+	apiUrls := make(map[string]string, len(urls))
+	for _, asset := range assets {
+		if asset.APIUrl != "" && slices.Contains(urls, asset.Url) {
+			apiUrls[asset.Url] = asset.APIUrl
+		}
+	}
+	return apiUrls
+}
+
+func (r GithubResolver) ResolveLatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, error) {
 	tmpBinaryInfo := binaryInfo
 	tmpBinaryInfo.Version = LatestVersion
 
-	data, err := r.callGithubReleaseEndpoint(tmpBinaryInfo)
-	return data.Name, err
+	data, err := r.callGithubReleaseEndpoint(ctx, tmpBinaryInfo)
+	return data.version(), err
+}
+
+func (r GithubResolver) ListVersions(ctx context.Context, binaryInfo dto.BinaryInfo) ([]string, error) {
+	var versions []string
+	for page := 1; page <= MaxReleasePages; page++ {
+		releasesUrl := r.reposAPIUrl + fmt.Sprintf(GHAPIReleasesSegmentTemplate, binaryInfo.FullName,
+			ReleasesPerPage, page)
+		var releases []GitHubReleaseResponse
+		if err := getJSON(createHttpRequest(ctx, releasesUrl), &releases); err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			if !release.Draft {
+				// the release endpoint looks a version up by its tag
+				versions = append(versions, release.TagName)
+			}
+		}
+		if len(releases) < ReleasesPerPage {
+			break
+		}
+	}
+	return versions, nil
 }
 
 func (r GithubResolver) Name() string {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"runtime"
 	"strings"
 	"testing"
@@ -13,9 +14,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
 	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
 )
 
+// TestMain keeps the tests away from the token of the environment or of gh
+func TestMain(m *testing.M) {
+	httpclient.SetGitHubToken("")
+	os.Exit(m.Run())
+}
+
 func newTestBinaryInfo() *dto.BinaryInfo {
 	return &dto.BinaryInfo{
 		Owner:    "foo",
@@ -65,11 +73,21 @@ func TestNewGithubResolver(t *testing.T) {
 func TestCreateHttpRequest(t *testing.T) {
 	t.Run("should properly setup the http request", func(t *testing.T) {
 		url := "https://example.com"
-		request := createHttpRequest(url)
+		request := createHttpRequest(t.Context(), url)
 		assert.Equal(t, url, request.URL.String())
 		assert.Equal(t, []string{AcceptHeader}, request.Header["Accept"])
 		assert.Equal(t, []string{UserAgentHeader}, request.Header["User-Agent"])
 		assert.NotEmpty(t, request.Header[http.CanonicalHeaderKey("X-GitHub-Api-Version")])
+		assert.Empty(t, request.Header.Get("Authorization"))
+	})
+
+	t.Run("should authenticate with the token", func(t *testing.T) {
+		httpclient.SetGitHubToken("secret")
+		defer httpclient.SetGitHubToken("")
+
+		request := createHttpRequest(t.Context(), "https://example.com")
+
+		assert.Equal(t, "Bearer secret", request.Header.Get("Authorization"))
 	})
 }
 
@@ -98,12 +116,108 @@ func TestResolve(t *testing.T) {
 		defer server.Close()
 
 		resolver := NewGithubResolver(server.URL)
-		url, err := resolver.Resolve(newTestBinaryInfo())
+		url, err := resolver.Resolve(t.Context(), newTestBinaryInfo())
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedURL, url)
 	})
 
+	t.Run("should download assets through the API when authenticated", func(t *testing.T) {
+		httpclient.SetGitHubToken("secret")
+		defer httpclient.SetGitHubToken("")
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasPrefix(r.URL.Path, GHAPIRepoSegment):
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				resp := GitHubReleaseResponse{
+					Name: "v1.0.0",
+					Assets: []GitHubReleaseResponseAsset{{
+						Url:    fmt.Sprintf("%s/download/bar-%s-%s", server.URL, runtime.GOOS, runtime.GOARCH),
+						APIUrl: server.URL + "/assets/1",
+					}},
+				}
+				assert.NoError(t, json.NewEncoder(w).Encode(resp))
+			case r.URL.Path == "/assets/1":
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				assert.Equal(t, httpclient.OctetStreamHeader, r.Header.Get("Accept"))
+				_, _ = io.WriteString(w, "private binary")
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		url, err := NewGithubResolver(server.URL).Resolve(t.Context(), newTestBinaryInfo())
+		require.NoError(t, err)
+		assert.Contains(t, url, "/download/", "the browser url is kept as asset url")
+
+		resp, err := httpclient.Client.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "private binary", string(data))
+	})
+
+	t.Run("should resolve checksum file", func(t *testing.T) {
+		assetURL := fmt.Sprintf("https://github.com/foo/bar/releases/download/v1.0.0/bar-%s-%s.tar.gz",
+			runtime.GOOS, runtime.GOARCH)
+		checksumURL := "https://github.com/foo/bar/releases/download/v1.0.0/checksums.txt"
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp := GitHubReleaseResponse{
+				Name:   "v1.0.0",
+				Assets: []GitHubReleaseResponseAsset{{Url: checksumURL}, {Url: assetURL}},
+			}
+			data, err := json.Marshal(resp)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+
+		binaryInfo := newTestBinaryInfo()
+		url, err := NewGithubResolver(server.URL).Resolve(t.Context(), binaryInfo)
+
+		assert.NoError(t, err)
+		assert.Equal(t, assetURL, url)
+		assert.Equal(t, checksumURL, binaryInfo.ChecksumURL)
+	})
+
+	t.Run("should keep the API url of the release files", func(t *testing.T) {
+		assetURL := fmt.Sprintf("https://github.com/foo/bar/releases/download/v1.0.0/bar-%s-%s.tar.gz",
+			runtime.GOOS, runtime.GOARCH)
+		checksumURL := "https://github.com/foo/bar/releases/download/v1.0.0/checksums.txt"
+		signatureURL := checksumURL + ".sig"
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp := GitHubReleaseResponse{
+				Name: "v1.0.0",
+				Assets: []GitHubReleaseResponseAsset{
+					{Url: checksumURL, APIUrl: "https://api.github.com/assets/1"},
+					{Url: signatureURL, APIUrl: "https://api.github.com/assets/2"},
+					{Url: assetURL, APIUrl: "https://api.github.com/assets/3"},
+					{Url: "https://github.com/foo/bar/releases/download/v1.0.0/bar.sbom",
+						APIUrl: "https://api.github.com/assets/4"},
+				},
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(resp))
+		}))
+		defer server.Close()
+
+		binaryInfo := newTestBinaryInfo()
+		_, err := NewGithubResolver(server.URL).Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{signatureURL}, binaryInfo.SignatureURLs)
+		assert.Equal(t, map[string]string{
+			checksumURL:  "https://api.github.com/assets/1",
+			signatureURL: "https://api.github.com/assets/2",
+			assetURL:     "https://api.github.com/assets/3",
+		}, binaryInfo.AssetAPIURLs, "only the files used by the install are kept")
+	})
+
 	t.Run("should resolve latest version", func(t *testing.T) {
 		testCases := []struct {
 			name       string
@@ -177,7 +291,7 @@ func TestResolve(t *testing.T) {
 				defer server.Close()
 
 				resolver := NewGithubResolver(server.URL)
-				url, err := resolver.Resolve(binaryInfo)
+				url, err := resolver.Resolve(t.Context(), binaryInfo)
 
 				assert.NoError(t, err)
 				if tc.empty {
@@ -212,7 +326,7 @@ func TestResolve(t *testing.T) {
 		defer server.Close()
 
 		resolver := NewGithubResolver(server.URL)
-		url, err := resolver.Resolve(newTestBinaryInfo())
+		url, err := resolver.Resolve(t.Context(), newTestBinaryInfo())
 
 		assert.NoError(t, err)
 		assert.Empty(t, url)
@@ -223,12 +337,28 @@ func TestResolve(t *testing.T) {
 		defer server.Close()
 
 		resolver := NewGithubResolver(server.URL)
-		url, err := resolver.Resolve(newTestBinaryInfo())
+		url, err := resolver.Resolve(t.Context(), newTestBinaryInfo())
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
 		assert.Empty(t, url)
 	})
 
+	t.Run("should report exhausted rate limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(httpclient.RateLimitRemainingHeader, "0")
+			w.Header().Set(httpclient.RateLimitResetHeader, "4102444800")
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		url, err := NewGithubResolver(server.URL).Resolve(t.Context(), newTestBinaryInfo())
+
+		var rateLimitErr *httpclient.RateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		assert.Contains(t, err.Error(), httpclient.GitHubTokenEnvVar)
+		assert.Empty(t, url)
+	})
+
 	t.Run("should handle invalid json answer", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			_, err := io.WriteString(w, "invalid-json")
@@ -237,7 +367,7 @@ func TestResolve(t *testing.T) {
 		defer server.Close()
 
 		resolver := NewGithubResolver(server.URL)
-		url, err := resolver.Resolve(newTestBinaryInfo())
+		url, err := resolver.Resolve(t.Context(), newTestBinaryInfo())
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to parse data")
 		assert.Empty(t, url)
@@ -276,13 +406,90 @@ func TestResolveLatestVersion(t *testing.T) {
 		defer server.Close()
 
 		resolver := NewGithubResolver(server.URL)
-		version, err := resolver.ResolveLatestVersion(*binaryInfo)
+		version, err := resolver.ResolveLatestVersion(t.Context(), *binaryInfo)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedVersion, version)
 	})
 }
 
+func TestListVersions(t *testing.T) {
+	t.Run("should list release tags of every page", func(t *testing.T) {
+		binaryInfo := newTestBinaryInfo()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/repos/"+binaryInfo.FullName+"/releases" {
+				http.Error(w, "wrong path", http.StatusBadRequest)
+				return
+			}
+			var releases []GitHubReleaseResponse
+			switch r.URL.Query().Get("page") {
+			case "1":
+				for i := range ReleasesPerPage {
+					releases = append(releases, GitHubReleaseResponse{Name: "Release", TagName: fmt.Sprintf("v1.%d.0", i)})
+				}
+			case "2":
+				releases = []GitHubReleaseResponse{{TagName: "v0.9.0"}, {TagName: "v2.0.0", Draft: true}}
+			}
+			data, err := json.Marshal(releases)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+
+		versions, err := NewGithubResolver(server.URL).ListVersions(t.Context(), *binaryInfo)
+
+		require.NoError(t, err)
+		assert.Len(t, versions, ReleasesPerPage+1)
+		assert.Equal(t, "v1.0.0", versions[0])
+		assert.Equal(t, "v0.9.0", versions[ReleasesPerPage])
+		assert.NotContains(t, versions, "v2.0.0", "drafts should be ignored")
+	})
+
+	t.Run("should handle error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		_, err := NewGithubResolver(server.URL).ListVersions(t.Context(), *newTestBinaryInfo())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
+	})
+}
+
+func TestIsGitHubAssetAPIUrl(t *testing.T) {
+	downloadUrl := "https://github.com/foo/bar/releases/download/v1.0.0/bar.tar.gz"
+	testCases := []struct {
+		name        string
+		downloadUrl string
+		apiUrl      string
+		expected    bool
+	}{
+		{"should accept a GitHub release file", downloadUrl, "https://api.github.com/repos/foo/bar/releases/assets/1",
+			true},
+		{"should refuse a foreign API host", downloadUrl, "https://evil.example/repos/foo/bar/releases/assets/1", false},
+		{"should refuse a plain http API url", downloadUrl, "http://api.github.com/repos/foo/bar/releases/assets/1",
+			false},
+		{"should refuse another API endpoint", downloadUrl, "https://api.github.com/user", false},
+		{"should refuse a foreign download host", "https://evil.example/bar.tar.gz",
+			"https://api.github.com/repos/foo/bar/releases/assets/1", false},
+		{"should refuse an invalid url", downloadUrl, "://", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsGitHubAssetAPIUrl(tc.downloadUrl, tc.apiUrl))
+		})
+	}
+}
+
+func TestReleaseVersion(t *testing.T) {
+	t.Run("should prefer release name over tag", func(t *testing.T) {
+		assert.Equal(t, "Release 1.0", GitHubReleaseResponse{Name: "Release 1.0", TagName: "v1.0.0"}.version())
+		assert.Equal(t, "v1.0.0", GitHubReleaseResponse{TagName: "v1.0.0"}.version())
+	})
+}
+
 func TestName(t *testing.T) {
 	got := NewGithubResolver("").Name()
 	assert.Equal(t, GithubResolverName, got)
@@ -0,0 +1,176 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+const (
+	GLBaseUrl                         = "https://gitlab.com"
+	GLAPIProjectsSegment              = "/api/v4/projects"
+	GLAPIReleaseSegmentTemplate       = "/%s/releases/%s"
+	GLAPIReleaseLatestSegmentTemplate = "/%s/releases/permalink/latest"
+	GLAPIReleasesSegmentTemplate      = "/%s/releases?per_page=%d&page=%d"
+	GitlabResolverName                = "gitlab"
+
+	// GitlabUrlEnvVar allows to target a self-managed instance
+	GitlabUrlEnvVar = "AZABOX_GITLAB_URL"
+)
+
+type GitlabResolver struct {
+	baseUrl string
+}
+
+type GitlabReleaseResponseLink struct {
+	Name           string `json:"name"`
+	Url            string `json:"url"`
+	DirectAssetUrl string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+type GitlabReleaseResponse struct {
+	Name    string `json:"name"`
+	TagName string `json:"tag_name"`
+	Assets  struct {
+		Links []GitlabReleaseResponseLink `json:"links"`
+	} `json:"assets"`
+}
+
+// GitlabBaseUrl returns the GitLab instance to use, gitlab.com unless overridden by env var
+func GitlabBaseUrl() string {
+	if baseUrl := os.Getenv(GitlabUrlEnvVar); baseUrl != "" {
+		return strings.TrimSuffix(baseUrl, "/")
+	}
+	return GLBaseUrl
+}
+
+func NewGitlabResolver(baseUrl string) *GitlabResolver {
+	return &GitlabResolver{
+		baseUrl: baseUrl,
+	}
+}
+
+func createGitlabHttpRequest(ctx context.Context, url string) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgentHeader)
+
+	return req
+}
+
+// instanceUrl returns the instance recorded at install time, or the resolver default
+func (r GitlabResolver) instanceUrl(binaryInfo dto.BinaryInfo) string {
+	if binaryInfo.Resolver == GitlabResolverName && binaryInfo.BaseURL != "" {
+		return binaryInfo.BaseURL
+	}
+	return r.baseUrl
+}
+
+func (r GitlabResolver) releaseUrl(binaryInfo dto.BinaryInfo) string {
+	// nested groups are supported by using the url encoded project path as id
+	projectsUrl := r.instanceUrl(binaryInfo) + GLAPIProjectsSegment
+	projectID := url.PathEscape(binaryInfo.FullName)
+	if binaryInfo.Version == LatestVersion {
+		return projectsUrl + fmt.Sprintf(GLAPIReleaseLatestSegmentTemplate, projectID)
+	}
+	return projectsUrl + fmt.Sprintf(GLAPIReleaseSegmentTemplate, projectID, url.PathEscape(binaryInfo.Version))
+}
+
+func (r GitlabResolver) callGitlabReleaseEndpoint(ctx context.Context, binaryInfo dto.BinaryInfo) (
+	GitlabReleaseResponse, error,
+) {
+	var data GitlabReleaseResponse
+	if err := getJSON(createGitlabHttpRequest(ctx, r.releaseUrl(binaryInfo)), &data); err != nil {
+		return GitlabReleaseResponse{}, err
+	}
+
+	return data, nil
+}
+
+// downloadUrl prefers the permanent direct asset url over the raw link,
+// both release asset links and generic package links are handled the same way
+func (l GitlabReleaseResponseLink) downloadUrl() string {
+	if l.DirectAssetUrl != "" {
+		return l.DirectAssetUrl
+	}
+	return l.Url
+}
+
+// fileName returns the name of the asset file, generic package links
+// are named after the package and not the file itself
+func (l GitlabReleaseResponseLink) fileName() string {
+	parsed, err := url.Parse(l.Url)
+	if err == nil && strings.Contains(parsed.Path, "/packages/generic/") {
+		return path.Base(parsed.Path)
+	}
+	if l.Name != "" {
+		return l.Name
+	}
+	return path.Base(l.Url)
+}
+
+func (r GitlabResolver) Resolve(ctx context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
+	logging.Logger().Debug("Resolve binary for gitlab", "binary", binaryInfo.Name, "owner",
+		binaryInfo.Owner, "version", binaryInfo.Version, "instance", r.instanceUrl(*binaryInfo))
+
+	data, err := r.callGitlabReleaseEndpoint(ctx, *binaryInfo)
+	if err != nil {
+		return "", err
+	}
+
+	for _, link := range data.Assets.Links {
+		if matchPlatform(link.fileName()) {
+			downloadURL := link.downloadUrl()
+			logging.Logger().Debug("download URL", "url", downloadURL, "name", binaryInfo.Name,
+				"platform", runtime.GOOS, "arch", runtime.GOARCH, "version", binaryInfo.Version,
+				"resolvedVersion", data.TagName, "linkType", link.LinkType)
+			binaryInfo.BaseURL = r.instanceUrl(*binaryInfo)
+			binaryInfo.InstalledVersion = data.TagName
+			binaryInfo.Resolver = GitlabResolverName
+			return downloadURL, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (r GitlabResolver) ResolveLatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, error) {
+	tmpBinaryInfo := binaryInfo
+	tmpBinaryInfo.Version = LatestVersion
+
+	data, err := r.callGitlabReleaseEndpoint(ctx, tmpBinaryInfo)
+	return data.TagName, err
+}
+
+func (r GitlabResolver) ListVersions(ctx context.Context, binaryInfo dto.BinaryInfo) ([]string, error) {
+	projectsUrl := r.instanceUrl(binaryInfo) + GLAPIProjectsSegment
+	var versions []string
+	for page := 1; page <= MaxReleasePages; page++ {
+		releasesUrl := projectsUrl + fmt.Sprintf(GLAPIReleasesSegmentTemplate, url.PathEscape(binaryInfo.FullName),
+			ReleasesPerPage, page)
+		var releases []GitlabReleaseResponse
+		if err := getJSON(createGitlabHttpRequest(ctx, releasesUrl), &releases); err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			versions = append(versions, release.TagName)
+		}
+		if len(releases) < ReleasesPerPage {
+			break
+		}
+	}
+	return versions, nil
+}
+
+func (r GitlabResolver) Name() string {
+	return GitlabResolverName
+}
@@ -0,0 +1,201 @@
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+func newGitlabTestBinaryInfo() *dto.BinaryInfo {
+	return &dto.BinaryInfo{
+		Owner:    "group/subgroup",
+		Name:     "bar",
+		Version:  "v1.0.0",
+		FullName: "group/subgroup/bar",
+	}
+}
+
+func newGitlabTestServer(t *testing.T, expectedURI string, resp GitlabReleaseResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI != expectedURI {
+			http.Error(w, "wrong path "+r.RequestURI, http.StatusBadRequest)
+			return
+		}
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+	}))
+}
+
+func newGitlabTestRelease(links ...GitlabReleaseResponseLink) GitlabReleaseResponse {
+	resp := GitlabReleaseResponse{Name: "Release v1.0.0", TagName: "v1.0.0"}
+	resp.Assets.Links = links
+	return resp
+}
+
+func TestGitlabBaseUrl(t *testing.T) {
+	t.Run("should default to gitlab.com", func(t *testing.T) {
+		t.Setenv(GitlabUrlEnvVar, "")
+		assert.Equal(t, GLBaseUrl, GitlabBaseUrl())
+	})
+
+	t.Run("should use self-managed instance from env var", func(t *testing.T) {
+		t.Setenv(GitlabUrlEnvVar, "https://gitlab.example.com/")
+		assert.Equal(t, "https://gitlab.example.com", GitlabBaseUrl())
+	})
+}
+
+func TestGitlabResolve(t *testing.T) {
+	t.Run("should resolve release asset link for nested group", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		assetName := fmt.Sprintf("bar-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+		expectedURL := "https://gitlab.com/group/subgroup/bar/-/releases/v1.0.0/downloads/" + assetName
+		server := newGitlabTestServer(t, "/api/v4/projects/group%2Fsubgroup%2Fbar/releases/v1.0.0",
+			newGitlabTestRelease(
+				GitlabReleaseResponseLink{Name: "checksums.txt", Url: "https://foo.bar/checksums.txt"},
+				GitlabReleaseResponseLink{
+					Name: assetName, Url: "https://foo.bar/uploads/1234/" + assetName,
+					DirectAssetUrl: expectedURL, LinkType: "other",
+				},
+			))
+		defer server.Close()
+
+		binaryInfo := newGitlabTestBinaryInfo()
+		url, err := NewGitlabResolver(server.URL).Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedURL, url)
+		assert.Equal(t, "v1.0.0", binaryInfo.InstalledVersion)
+		assert.Equal(t, GitlabResolverName, binaryInfo.Resolver)
+		assert.Equal(t, server.URL, binaryInfo.BaseURL)
+	})
+
+	t.Run("should resolve generic package link", func(t *testing.T) {
+		expectedURL := fmt.Sprintf("https://gitlab.com/api/v4/projects/42/packages/generic/bar/v1.0.0/bar_%s_%s",
+			runtime.GOOS, runtime.GOARCH)
+		server := newGitlabTestServer(t, "/api/v4/projects/group%2Fsubgroup%2Fbar/releases/permalink/latest",
+			newGitlabTestRelease(GitlabReleaseResponseLink{Name: "bar", Url: expectedURL, LinkType: "package"}))
+		defer server.Close()
+
+		binaryInfo := newGitlabTestBinaryInfo()
+		binaryInfo.Version = LatestVersion
+		url, err := NewGitlabResolver(server.URL).Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedURL, url)
+		assert.Equal(t, "v1.0.0", binaryInfo.InstalledVersion)
+	})
+
+	t.Run("should use instance recorded in binary info", func(t *testing.T) {
+		assetName := fmt.Sprintf("bar-%s-%s", runtime.GOOS, runtime.GOARCH)
+		server := newGitlabTestServer(t, "/api/v4/projects/group%2Fsubgroup%2Fbar/releases/v1.0.0",
+			newGitlabTestRelease(GitlabReleaseResponseLink{Name: assetName, Url: "https://foo.bar/" + assetName}))
+		defer server.Close()
+
+		binaryInfo := newGitlabTestBinaryInfo()
+		binaryInfo.Resolver = GitlabResolverName
+		binaryInfo.BaseURL = server.URL
+		url, err := NewGitlabResolver(GLBaseUrl).Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.bar/"+assetName, url)
+	})
+
+	t.Run("should handle no match", func(t *testing.T) {
+		server := newGitlabTestServer(t, "/api/v4/projects/group%2Fsubgroup%2Fbar/releases/v1.0.0",
+			newGitlabTestRelease(GitlabReleaseResponseLink{Name: "bar-plan9-mips.deb", Url: "https://foo.bar/bar"}))
+		defer server.Close()
+
+		url, err := NewGitlabResolver(server.URL).Resolve(t.Context(), newGitlabTestBinaryInfo())
+
+		assert.NoError(t, err)
+		assert.Empty(t, url)
+	})
+
+	t.Run("should handle request error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		url, err := NewGitlabResolver(server.URL).Resolve(t.Context(), newGitlabTestBinaryInfo())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
+		assert.Empty(t, url)
+	})
+
+	t.Run("should handle invalid json answer", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.WriteString(w, "invalid-json")
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		url, err := NewGitlabResolver(server.URL).Resolve(t.Context(), newGitlabTestBinaryInfo())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse data")
+		assert.Empty(t, url)
+	})
+}
+
+func TestGitlabResolveLatestVersion(t *testing.T) {
+	t.Run("should resolve latest version from tag", func(t *testing.T) {
+		server := newGitlabTestServer(t, "/api/v4/projects/group%2Fsubgroup%2Fbar/releases/permalink/latest",
+			newGitlabTestRelease())
+		defer server.Close()
+
+		version, err := NewGitlabResolver(server.URL).ResolveLatestVersion(t.Context(), *newGitlabTestBinaryInfo())
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", version)
+	})
+}
+
+func TestGitlabListVersions(t *testing.T) {
+	t.Run("should list release tags", func(t *testing.T) {
+		binaryInfo := newGitlabTestBinaryInfo()
+		expectedURI := GLAPIProjectsSegment + fmt.Sprintf(GLAPIReleasesSegmentTemplate,
+			"group%2Fsubgroup%2Fbar", ReleasesPerPage, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.RequestURI != expectedURI {
+				http.Error(w, "wrong path "+r.RequestURI, http.StatusBadRequest)
+				return
+			}
+			data, err := json.Marshal([]GitlabReleaseResponse{{TagName: "v1.1.0"}, {TagName: "v1.0.0"}})
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+
+		versions, err := NewGitlabResolver(server.URL).ListVersions(t.Context(), *binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1.1.0", "v1.0.0"}, versions)
+	})
+
+	t.Run("should handle error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		_, err := NewGitlabResolver(server.URL).ListVersions(t.Context(), *newGitlabTestBinaryInfo())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
+	})
+}
+
+func TestGitlabName(t *testing.T) {
+	got := NewGitlabResolver("").Name()
+	assert.Equal(t, GitlabResolverName, got)
+}
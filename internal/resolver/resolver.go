@@ -1,28 +1,68 @@
 package resolver
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
 
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
-	"gitlab.com/ludovic-alarcon/azabox/internal/types"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
+	"gitlab.com/ludovic-alarcon/azabox/internal/installer"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/platform"
+	"gitlab.com/ludovic-alarcon/azabox/internal/semver"
 )
 
 var (
 	once     sync.Once
 	registry *RegistryResolver
+
+	ErrNotResolved = errors.New("binary not found")
 )
 
-const LatestVersion = "latest"
+const (
+	LatestVersion = "latest"
+
+	// ReleasesPerPage and MaxReleasePages bound the listing of releases for version constraints
+	ReleasesPerPage = 100
+	MaxReleasePages = 10
+
+	// Resolvers are tried from the lowest priority value to the highest,
+	// resolvers with the same priority keep their registration order
+	GithubPriority  = 10
+	GitlabPriority  = 20
+	URLPriority     = 30
+	DefaultPriority = 100
+)
 
 type Resolver interface {
 	Name() string
-	Resolve(*dto.BinaryInfo) (string, error)
-	ResolveLatestVersion(dto.BinaryInfo) (string, error)
+	Resolve(context.Context, *dto.BinaryInfo) (string, error)
+	ResolveLatestVersion(context.Context, dto.BinaryInfo) (string, error)
+}
+
+// VersionLister is implemented by resolvers able to list the published versions of a binary,
+// it is required to resolve version constraints
+type VersionLister interface {
+	ListVersions(context.Context, dto.BinaryInfo) ([]string, error)
+}
+
+type registeredResolver struct {
+	resolver Resolver
+	priority int
 }
 
 type RegistryResolver struct {
 	mutex     sync.RWMutex
-	resolvers types.Set[Resolver]
+	resolvers []registeredResolver
 }
 
 func GetRegistryResolver() *RegistryResolver {
@@ -34,29 +74,221 @@ func GetRegistryResolver() *RegistryResolver {
 
 func newRegistryResolver() *RegistryResolver {
 	return &RegistryResolver{
-		resolvers: types.NewSet[Resolver](1),
+		resolvers: make([]registeredResolver, 0, 3),
 	}
 }
 
 func (r *RegistryResolver) WithDefaultResolvers() *RegistryResolver {
-	r.Register(NewGithubResolver(GHBaseAPIUrl))
+	r.RegisterWithPriority(NewGithubResolver(GHBaseAPIUrl), GithubPriority)
+	r.RegisterWithPriority(NewGitlabResolver(GitlabBaseUrl()), GitlabPriority)
+	r.RegisterWithPriority(NewURLResolver(), URLPriority)
 	return r
 }
 
 func (r *RegistryResolver) Register(resolver Resolver) {
+	r.RegisterWithPriority(resolver, DefaultPriority)
+}
+
+// RegisterWithPriority adds the resolver to the chain, registering an already known
+// resolver only updates its priority
+func (r *RegistryResolver) RegisterWithPriority(resolver Resolver, priority int) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	r.resolvers.Add(resolver)
+	r.resolvers = slices.DeleteFunc(r.resolvers, func(registered registeredResolver) bool {
+		return registered.resolver == resolver
+	})
+	r.resolvers = append(r.resolvers, registeredResolver{resolver: resolver, priority: priority})
+	slices.SortStableFunc(r.resolvers, func(a, b registeredResolver) int {
+		return cmp.Compare(a.priority, b.priority)
+	})
 }
 
 func (r *RegistryResolver) Unregister(resolver Resolver) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	r.resolvers.Remove(resolver)
+	r.resolvers = slices.DeleteFunc(r.resolvers, func(registered registeredResolver) bool {
+		return registered.resolver == resolver
+	})
 }
 
-func (r *RegistryResolver) GetResolvers() types.Set[Resolver] {
+func (r *RegistryResolver) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.resolvers = r.resolvers[:0]
+}
+
+// GetResolvers returns the resolvers in the order they are tried
+func (r *RegistryResolver) GetResolvers() []Resolver {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
-	return r.resolvers
+	resolvers := make([]Resolver, 0, len(r.resolvers))
+	for _, registered := range r.resolvers {
+		resolvers = append(resolvers, registered.resolver)
+	}
+	return resolvers
+}
+
+func (r *RegistryResolver) Lookup(name string) (Resolver, bool) {
+	for _, resolver := range r.GetResolvers() {
+		if resolver.Name() == name {
+			return resolver, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve tries each resolver in order and returns the first download url found.
+// When the binary already names a resolver, only that one is used.
+// If none match, the returned error wraps ErrNotResolved with the reason of each resolver.
+func (r *RegistryResolver) Resolve(ctx context.Context, binaryInfo *dto.BinaryInfo) (string, Resolver, error) {
+	resolvers := r.GetResolvers()
+	if binaryInfo.Resolver != "" {
+		resolver, ok := r.Lookup(binaryInfo.Resolver)
+		if !ok {
+			return "", nil, fmt.Errorf("unknown resolver %s", binaryInfo.Resolver)
+		}
+		resolvers = []Resolver{resolver}
+	}
+
+	failures := make([]error, 0, len(resolvers))
+	for _, resolver := range resolvers {
+		url, err := ResolveMatching(ctx, resolver, binaryInfo)
+		if err == nil && url != "" {
+			logging.Logger().Debug("Matched resolver", "name", resolver.Name(), "url", url)
+			return url, resolver, nil
+		}
+		if err == nil {
+			err = errors.New("no asset matching the current platform")
+		}
+		logging.Logger().Debug("Resolver did not match", "name", resolver.Name(), "reason", err)
+		failures = append(failures, fmt.Errorf("  - %s: %w", resolver.Name(), err))
+		// no need to try the next resolvers once the command is cancelled
+		if ctx.Err() != nil {
+			return "", nil, ctx.Err()
+		}
+	}
+
+	if len(failures) == 0 {
+		return "", nil, fmt.Errorf("%w for \"%s\": no resolver registered", ErrNotResolved, binaryInfo.FullName)
+	}
+	return "", nil, fmt.Errorf("%w for \"%s\" with version \"%s\":\n%w", ErrNotResolved,
+		binaryInfo.FullName, binaryInfo.Version, errors.Join(failures...))
+}
+
+// getJSON sends the request and decodes the JSON response in data
+func getJSON(req *http.Request, data any) error {
+	resp, err := httpclient.DoCached(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request error: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return fmt.Errorf("failed to parse data: %w", err)
+	}
+	return nil
+}
+
+// LatestMatchingVersion returns the latest version of the binary, inside its constraint when it has one
+func LatestMatchingVersion(ctx context.Context, resolver Resolver, binaryInfo dto.BinaryInfo) (string, error) {
+	if binaryInfo.Constraint == "" {
+		return resolver.ResolveLatestVersion(ctx, binaryInfo)
+	}
+
+	constraint, err := semver.ParseConstraint(binaryInfo.Constraint)
+	if err != nil {
+		return "", err
+	}
+	lister, ok := resolver.(VersionLister)
+	if !ok {
+		return "", fmt.Errorf("version constraints are not supported by resolver %s", resolver.Name())
+	}
+	versions, err := lister.ListVersions(ctx, binaryInfo)
+	if err != nil {
+		return "", err
+	}
+
+	version, ok := constraint.Highest(versions)
+	if !ok {
+		return "", fmt.Errorf("no version of %s matches constraint %s", binaryInfo.FullName, constraint)
+	}
+	logging.Logger().Debug("Matched constraint", "binary", binaryInfo.FullName, "constraint",
+		binaryInfo.Constraint, "version", version)
+	return version, nil
+}
+
+// ResolveMatching resolves the binary, a constraint is first narrowed to the highest matching version
+func ResolveMatching(ctx context.Context, resolver Resolver, binaryInfo *dto.BinaryInfo) (string, error) {
+	if binaryInfo.Constraint == "" {
+		return resolver.Resolve(ctx, binaryInfo)
+	}
+
+	version, err := LatestMatchingVersion(ctx, resolver, *binaryInfo)
+	if err != nil {
+		return "", err
+	}
+	requested := binaryInfo.Version
+	binaryInfo.Version = version
+	url, err := resolver.Resolve(ctx, binaryInfo)
+	binaryInfo.Version = requested
+	return url, err
+}
+
+// matchPlatform reports whether an asset targets the current os and architecture
+// in a format the installer supports
+func matchPlatform(asset string) bool {
+	os := runtime.GOOS
+	arch := runtime.GOARCH
+	archNormalized := platform.NormalizeArch(arch)
+	logging.Logger().Debug("os info", "os", os, "arch", arch, "normalized", archNormalized)
+
+	name := strings.ToLower(asset)
+	return strings.Contains(name, os) &&
+		(strings.Contains(name, arch) || strings.Contains(name, archNormalized)) &&
+		installer.IsSupportedFormat(name)
+}
+
+// checksumAsset returns the url of the checksum file published for an asset among the release assets,
+// a dedicated "<asset>.sha256" file is preferred over a combined one like checksums.txt or SHA256SUMS
+func checksumAsset(assetUrl string, assetUrls []string) string {
+	assetName := path.Base(assetUrl)
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		for _, candidate := range assetUrls {
+			if path.Base(candidate) == assetName+suffix {
+				return candidate
+			}
+		}
+	}
+
+	for _, candidate := range assetUrls {
+		name := strings.ToLower(path.Base(candidate))
+		if strings.HasSuffix(name, "checksums.txt") || strings.HasPrefix(name, "sha256sums") {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// signatureAssets returns the urls of the detached signatures published for an asset or its checksum file
+func signatureAssets(assetUrl, checksumUrl string, assetUrls []string) []string {
+	signed := []string{path.Base(assetUrl)}
+	if checksumUrl != "" {
+		signed = append(signed, path.Base(checksumUrl))
+	}
+
+	var signatures []string
+	for _, name := range signed {
+		for _, suffix := range []string{".sig", ".bundle", ".sigstore.json", ".minisig", ".asc", ".gpg"} {
+			for _, candidate := range assetUrls {
+				if path.Base(candidate) == name+suffix {
+					signatures = append(signatures, candidate)
+				}
+			}
+		}
+	}
+	return signatures
 }
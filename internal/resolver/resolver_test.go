@@ -1,20 +1,23 @@
 package resolver
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
 )
 
 type DummyResolver struct{}
 
-func (r DummyResolver) Resolve(*dto.BinaryInfo) (string, error) {
+func (r DummyResolver) Resolve(context.Context, *dto.BinaryInfo) (string, error) {
 	return "", nil
 }
 
-func (r DummyResolver) ResolveLatestVersion(dto.BinaryInfo) (string, error) {
+func (r DummyResolver) ResolveLatestVersion(context.Context, dto.BinaryInfo) (string, error) {
 	return "0.0.0", nil
 }
 
@@ -22,6 +25,35 @@ func (r DummyResolver) Name() string {
 	return "dummy"
 }
 
+type namedResolver struct {
+	name string
+	url  string
+	err  error
+}
+
+func (r *namedResolver) Resolve(_ context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
+	if r.url != "" {
+		binaryInfo.Resolver = r.name
+	}
+	return r.url, r.err
+}
+
+func (r *namedResolver) ResolveLatestVersion(context.Context, dto.BinaryInfo) (string, error) {
+	return "0.0.0", nil
+}
+
+func (r *namedResolver) Name() string {
+	return r.name
+}
+
+func resolverNames(resolvers []Resolver) []string {
+	names := make([]string, 0, len(resolvers))
+	for _, resolver := range resolvers {
+		names = append(names, resolver.Name())
+	}
+	return names
+}
+
 func TestRegistry(t *testing.T) {
 	t.Run("should create a registry", func(t *testing.T) {
 		registry := newRegistryResolver()
@@ -37,7 +69,7 @@ func TestRegistry(t *testing.T) {
 
 		resolvers := registry.GetResolvers()
 		require.Len(t, resolvers, 1, "register should be register")
-		assert.Equal(t, dummyResolver, resolvers.ToSlice()[0])
+		assert.Equal(t, dummyResolver, resolvers[0])
 
 		registry.Unregister(dummyResolver)
 		resolvers = registry.GetResolvers()
@@ -54,7 +86,7 @@ func TestRegistry(t *testing.T) {
 
 		resolvers := registry.GetResolvers()
 		require.Len(t, resolvers, 1)
-		assert.Equal(t, dummyResolver, resolvers.ToSlice()[0])
+		assert.Equal(t, dummyResolver, resolvers[0])
 	})
 }
 
@@ -73,7 +105,7 @@ func TestGetRegistry(t *testing.T) {
 		resolvers := GetRegistryResolver().GetResolvers()
 
 		require.Len(t, resolvers, 1)
-		assert.IsType(t, &DummyResolver{}, resolvers.ToSlice()[0])
+		assert.IsType(t, &DummyResolver{}, resolvers[0])
 	})
 }
 
@@ -82,7 +114,267 @@ func TestWithDefaultResolvers(t *testing.T) {
 		registry := newRegistryResolver().WithDefaultResolvers()
 		resolvers := registry.GetResolvers()
 
-		require.Len(t, resolvers, 1)
-		assert.IsType(t, &GithubResolver{}, resolvers.ToSlice()[0])
+		require.Len(t, resolvers, 3)
+		assert.Equal(t, []string{GithubResolverName, GitlabResolverName, URLResolverName},
+			resolverNames(resolvers), "default resolvers should be ordered by priority")
+	})
+}
+
+func TestRegistryOrder(t *testing.T) {
+	t.Run("should order resolvers by priority then registration", func(t *testing.T) {
+		registry := newRegistryResolver()
+		first := &namedResolver{name: "first"}
+		second := &namedResolver{name: "second"}
+		third := &namedResolver{name: "third"}
+		last := &namedResolver{name: "last"}
+
+		registry.Register(last)
+		registry.RegisterWithPriority(second, 20)
+		registry.RegisterWithPriority(third, 20)
+		registry.RegisterWithPriority(first, 10)
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, []string{"first", "second", "third", "last"},
+				resolverNames(registry.GetResolvers()))
+		}
+	})
+
+	t.Run("should update priority on registration of a known resolver", func(t *testing.T) {
+		registry := newRegistryResolver()
+		first := &namedResolver{name: "first"}
+		second := &namedResolver{name: "second"}
+
+		registry.RegisterWithPriority(first, 10)
+		registry.RegisterWithPriority(second, 20)
+		registry.RegisterWithPriority(first, 30)
+
+		assert.Equal(t, []string{"second", "first"}, resolverNames(registry.GetResolvers()))
+	})
+
+	t.Run("should lookup and clear resolvers", func(t *testing.T) {
+		registry := newRegistryResolver()
+		first := &namedResolver{name: "first"}
+		registry.Register(first)
+
+		got, ok := registry.Lookup("first")
+		assert.True(t, ok)
+		assert.Equal(t, first, got)
+		_, ok = registry.Lookup("unknown")
+		assert.False(t, ok)
+
+		registry.Clear()
+		assert.Empty(t, registry.GetResolvers())
+	})
+}
+
+func TestRegistryResolve(t *testing.T) {
+	t.Run("should return the first matching resolver in order", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		registry := newRegistryResolver()
+		failing := &namedResolver{name: "failing", err: errors.New("boom")}
+		matching := &namedResolver{name: "matching", url: "https://foo.bar/first"}
+		other := &namedResolver{name: "other", url: "https://foo.bar/other"}
+		registry.RegisterWithPriority(other, 30)
+		registry.RegisterWithPriority(matching, 20)
+		registry.RegisterWithPriority(failing, 10)
+
+		binaryInfo := &dto.BinaryInfo{FullName: "foo/foo"}
+		url, resolver, err := registry.Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.bar/first", url)
+		assert.Equal(t, matching, resolver)
+	})
+
+	t.Run("should only use the resolver set on the binary", func(t *testing.T) {
+		registry := newRegistryResolver()
+		registry.RegisterWithPriority(&namedResolver{name: "first", url: "https://foo.bar/first"}, 10)
+		registry.RegisterWithPriority(&namedResolver{name: "forced", url: "https://foo.bar/forced"}, 20)
+
+		binaryInfo := &dto.BinaryInfo{FullName: "foo/foo", Resolver: "forced"}
+		url, resolver, err := registry.Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.bar/forced", url)
+		assert.Equal(t, "forced", resolver.Name())
+
+		binaryInfo.Resolver = "unknown"
+		_, _, err = registry.Resolve(t.Context(), binaryInfo)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown resolver")
+	})
+
+	t.Run("should report every failure reason", func(t *testing.T) {
+		registry := newRegistryResolver()
+		registry.RegisterWithPriority(&namedResolver{name: "first", err: errors.New("request error: 404")}, 10)
+		registry.RegisterWithPriority(&namedResolver{name: "second"}, 20)
+
+		binaryInfo := &dto.BinaryInfo{FullName: "foo/foo", Version: "latest"}
+		url, resolver, err := registry.Resolve(t.Context(), binaryInfo)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotResolved)
+		assert.Empty(t, url)
+		assert.Nil(t, resolver)
+		assert.Contains(t, err.Error(), "first: request error: 404")
+		assert.Contains(t, err.Error(), "second: no asset matching the current platform")
+	})
+
+	t.Run("should stop at the first failure once cancelled", func(t *testing.T) {
+		registry := newRegistryResolver()
+		registry.RegisterWithPriority(&namedResolver{name: "first", err: errors.New("request canceled")}, 10)
+		registry.RegisterWithPriority(&namedResolver{name: "second", url: "https://foo.bar/second"}, 20)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		url, resolver, err := registry.Resolve(ctx, &dto.BinaryInfo{FullName: "foo/foo"})
+
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, url)
+		assert.Nil(t, resolver)
+	})
+
+	t.Run("should handle empty registry", func(t *testing.T) {
+		_, _, err := newRegistryResolver().Resolve(t.Context(), &dto.BinaryInfo{FullName: "foo/foo"})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotResolved)
+		assert.Contains(t, err.Error(), "no resolver registered")
+	})
+}
+
+func TestChecksumAsset(t *testing.T) {
+	const base = "https://github.com/foo/bar/releases/download/v1.0.0/"
+	asset := base + "bar_linux_amd64.tar.gz"
+	testCases := []struct {
+		name     string
+		assets   []string
+		expected string
+	}{
+		{name: "no checksum", assets: []string{asset}, expected: ""},
+		{name: "checksums.txt", assets: []string{asset, base + "checksums.txt"}, expected: base + "checksums.txt"},
+		{
+			name:     "prefixed checksums",
+			assets:   []string{asset, base + "bar_1.0.0_checksums.txt"},
+			expected: base + "bar_1.0.0_checksums.txt",
+		},
+		{name: "SHA256SUMS", assets: []string{asset, base + "SHA256SUMS"}, expected: base + "SHA256SUMS"},
+		{
+			name:     "dedicated file preferred",
+			assets:   []string{base + "checksums.txt", asset, asset + ".sha256"},
+			expected: asset + ".sha256",
+		},
+		{
+			name:     "other asset file ignored",
+			assets:   []string{asset, base + "bar_darwin_arm64.tar.gz.sha256"},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, checksumAsset(asset, tc.assets))
+		})
+	}
+}
+
+func TestSignatureAssets(t *testing.T) {
+	const base = "https://github.com/foo/bar/releases/download/v1.0.0/"
+	asset := base + "bar_linux_amd64.tar.gz"
+	assets := []string{
+		asset, asset + ".sig", asset + ".minisig", base + "bar_darwin_arm64.tar.gz.sig",
+		base + "checksums.txt", base + "checksums.txt.sigstore.json", base + "checksums.txt.pem",
+	}
+
+	t.Run("should find signatures of asset and checksum file", func(t *testing.T) {
+		signatures := signatureAssets(asset, base+"checksums.txt", assets)
+
+		assert.Equal(t, []string{asset + ".sig", asset + ".minisig", base + "checksums.txt.sigstore.json"}, signatures)
+	})
+
+	t.Run("should ignore checksum signatures without checksum file", func(t *testing.T) {
+		signatures := signatureAssets(asset, "", assets)
+
+		assert.Equal(t, []string{asset + ".sig", asset + ".minisig"}, signatures)
+	})
+}
+
+type listingResolver struct {
+	namedResolver
+	versions []string
+	resolved string
+}
+
+func (r *listingResolver) Resolve(_ context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
+	r.resolved = binaryInfo.Version
+	binaryInfo.InstalledVersion = binaryInfo.Version
+	return "https://foo.bar/" + binaryInfo.Version, nil
+}
+
+func (r *listingResolver) ListVersions(context.Context, dto.BinaryInfo) ([]string, error) {
+	return r.versions, r.err
+}
+
+func TestLatestMatchingVersion(t *testing.T) {
+	logging.UseInMemoryLogger()
+	lister := &listingResolver{
+		namedResolver: namedResolver{name: "lister"},
+		versions:      []string{"v1.29.0", "v1.30.2", "v1.31.4", "v1.32.0", "nightly"},
+	}
+
+	t.Run("should use latest version without constraint", func(t *testing.T) {
+		version, err := LatestMatchingVersion(t.Context(), lister, dto.BinaryInfo{FullName: "foo/foo"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "0.0.0", version)
+	})
+
+	t.Run("should return the highest version in constraint", func(t *testing.T) {
+		version, err := LatestMatchingVersion(t.Context(), lister,
+			dto.BinaryInfo{FullName: "foo/foo", Constraint: ">=1.30,<1.32"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.31.4", version)
+	})
+
+	t.Run("should handle no matching version", func(t *testing.T) {
+		_, err := LatestMatchingVersion(t.Context(), lister, dto.BinaryInfo{FullName: "foo/foo", Constraint: "^2"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no version of foo/foo matches constraint ^2")
+	})
+
+	t.Run("should handle resolver without listing", func(t *testing.T) {
+		_, err := LatestMatchingVersion(t.Context(), &namedResolver{name: "plain"}, dto.BinaryInfo{Constraint: "~1"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not supported by resolver plain")
+	})
+
+	t.Run("should handle listing error", func(t *testing.T) {
+		failing := &listingResolver{namedResolver: namedResolver{name: "failing", err: errors.New("boom")}}
+
+		_, err := LatestMatchingVersion(t.Context(), failing, dto.BinaryInfo{Constraint: "~1"})
+
+		require.Error(t, err)
+		assert.Equal(t, "boom", err.Error())
+	})
+}
+
+func TestResolveMatching(t *testing.T) {
+	t.Run("should resolve the matching version and keep the constraint as requested version", func(t *testing.T) {
+		lister := &listingResolver{
+			namedResolver: namedResolver{name: "lister"},
+			versions:      []string{"v1.2.0", "v1.2.7", "v1.3.0"},
+		}
+		binaryInfo := &dto.BinaryInfo{FullName: "foo/foo", Version: "~1.2", Constraint: "~1.2"}
+
+		url, err := ResolveMatching(t.Context(), lister, binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.bar/v1.2.7", url)
+		assert.Equal(t, "v1.2.7", lister.resolved)
+		assert.Equal(t, "v1.2.7", binaryInfo.InstalledVersion)
+		assert.Equal(t, "~1.2", binaryInfo.Version)
 	})
 }
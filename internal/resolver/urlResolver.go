@@ -0,0 +1,154 @@
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/httpclient"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+	"gitlab.com/ludovic-alarcon/azabox/internal/platform"
+)
+
+const (
+	URLResolverName = "url"
+)
+
+var ErrNoVersionSource = errors.New("no latest version url provided, use an explicit version")
+
+// VersionProvider discovers the latest version of a binary installed from an url template
+type VersionProvider interface {
+	LatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, error)
+}
+
+// TextVersionProvider reads the version from a plain text document (e.g. a "stable.txt" file),
+// the url is itself a template rendered with the binary info
+type TextVersionProvider struct{}
+
+type URLResolver struct {
+	versionProvider VersionProvider
+}
+
+type urlTemplateData struct {
+	Name           string
+	Version        string
+	OS             string
+	Arch           string
+	NormalizedArch string
+	Vars           map[string]string
+}
+
+func NewURLResolver() *URLResolver {
+	return &URLResolver{
+		versionProvider: TextVersionProvider{},
+	}
+}
+
+func (r *URLResolver) WithVersionProvider(provider VersionProvider) *URLResolver {
+	r.versionProvider = provider
+	return r
+}
+
+func renderUrlTemplate(rawTemplate string, binaryInfo dto.BinaryInfo, version string) (string, error) {
+	tmpl, err := template.New(binaryInfo.Name).Option("missingkey=error").Parse(rawTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid url template: %w", err)
+	}
+
+	var sb strings.Builder
+	err = tmpl.Execute(&sb, urlTemplateData{
+		Name:           binaryInfo.Name,
+		Version:        version,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		NormalizedArch: platform.NormalizeArch(runtime.GOARCH),
+		Vars:           binaryInfo.TemplateVars,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render url template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (p TextVersionProvider) LatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, error) {
+	if binaryInfo.LatestVersionURL == "" {
+		return "", ErrNoVersionSource
+	}
+	url, err := renderUrlTemplate(binaryInfo.LatestVersionURL, binaryInfo, "")
+	if err != nil {
+		return "", err
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", UserAgentHeader)
+	resp, err := httpclient.DoCached(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request error: %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	if scanner.Scan() {
+		if version := strings.TrimSpace(scanner.Text()); version != "" {
+			return version, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no version found at %s", url)
+}
+
+func (r URLResolver) Resolve(ctx context.Context, binaryInfo *dto.BinaryInfo) (string, error) {
+	logging.Logger().Debug("Resolve binary for url", "binary", binaryInfo.Name, "template",
+		binaryInfo.URLTemplate, "version", binaryInfo.Version)
+
+	if binaryInfo.URLTemplate == "" {
+		return "", errors.New("no url template provided")
+	}
+
+	version := binaryInfo.Version
+	if version == LatestVersion || version == "" {
+		latest, err := r.versionProvider.LatestVersion(ctx, *binaryInfo)
+		if err != nil {
+			return "", err
+		}
+		version = latest
+	}
+
+	url, err := renderUrlTemplate(binaryInfo.URLTemplate, *binaryInfo, version)
+	if err != nil {
+		return "", err
+	}
+
+	logging.Logger().Debug("download URL", "url", url, "name", binaryInfo.Name,
+		"platform", runtime.GOOS, "arch", runtime.GOARCH, "version", binaryInfo.Version,
+		"resolvedVersion", version)
+	binaryInfo.InstalledVersion = version
+	binaryInfo.Resolver = URLResolverName
+	return url, nil
+}
+
+func (r URLResolver) ResolveLatestVersion(ctx context.Context, binaryInfo dto.BinaryInfo) (string, error) {
+	version, err := r.versionProvider.LatestVersion(ctx, binaryInfo)
+	if errors.Is(err, ErrNoVersionSource) {
+		// nothing to discover from, stay on the installed version
+		logging.Logger().Debug("no latest version source", "binary", binaryInfo.Name)
+		return binaryInfo.InstalledVersion, nil
+	}
+	return version, err
+}
+
+func (r URLResolver) Name() string {
+	return URLResolverName
+}
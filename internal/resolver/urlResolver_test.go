@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/ludovic-alarcon/azabox/internal/dto"
+	"gitlab.com/ludovic-alarcon/azabox/internal/logging"
+)
+
+type fakeVersionProvider struct {
+	version string
+	err     error
+}
+
+func (p fakeVersionProvider) LatestVersion(context.Context, dto.BinaryInfo) (string, error) {
+	return p.version, p.err
+}
+
+func newURLTestBinaryInfo(version string) *dto.BinaryInfo {
+	return &dto.BinaryInfo{
+		Owner:        "kubectl",
+		Name:         "kubectl",
+		FullName:     "kubectl/kubectl",
+		Version:      version,
+		URLTemplate:  "https://dl.k8s.io/release/{{.Version}}/bin/{{.OS}}/{{.Arch}}/{{.Name}}{{.Vars.ext}}",
+		TemplateVars: map[string]string{"ext": ".exe"},
+	}
+}
+
+func TestURLResolve(t *testing.T) {
+	t.Run("should render url template", func(t *testing.T) {
+		logging.UseInMemoryLogger()
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+
+		url, err := NewURLResolver().Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("https://dl.k8s.io/release/v1.31.0/bin/%s/%s/kubectl.exe",
+			runtime.GOOS, runtime.GOARCH), url)
+		assert.Equal(t, "v1.31.0", binaryInfo.InstalledVersion)
+		assert.Equal(t, URLResolverName, binaryInfo.Resolver)
+	})
+
+	t.Run("should discover latest version", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo(LatestVersion)
+		resolver := NewURLResolver().WithVersionProvider(fakeVersionProvider{version: "v1.32.1"})
+
+		url, err := resolver.Resolve(t.Context(), binaryInfo)
+
+		require.NoError(t, err)
+		assert.Contains(t, url, "/release/v1.32.1/")
+		assert.Equal(t, "v1.32.1", binaryInfo.InstalledVersion)
+	})
+
+	t.Run("should handle missing template", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.URLTemplate = ""
+
+		url, err := NewURLResolver().Resolve(t.Context(), binaryInfo)
+
+		require.Error(t, err)
+		assert.Empty(t, url)
+	})
+
+	t.Run("should handle invalid template", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.URLTemplate = "https://foo.bar/{{.Version"
+
+		_, err := NewURLResolver().Resolve(t.Context(), binaryInfo)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid url template")
+	})
+
+	t.Run("should handle unknown variable", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.TemplateVars = nil
+
+		_, err := NewURLResolver().Resolve(t.Context(), binaryInfo)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to render url template")
+	})
+
+	t.Run("should handle latest without version source", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo(LatestVersion)
+
+		_, err := NewURLResolver().Resolve(t.Context(), binaryInfo)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNoVersionSource)
+	})
+}
+
+func TestURLResolveLatestVersion(t *testing.T) {
+	t.Run("should read version from stable.txt like url", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/kubectl/stable.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			_, err := io.WriteString(w, "  v1.32.1\nignored\n")
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.LatestVersionURL = server.URL + "/{{.Name}}/stable.txt"
+
+		version, err := NewURLResolver().ResolveLatestVersion(t.Context(), *binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.32.1", version)
+	})
+
+	t.Run("should stay on installed version without version source", func(t *testing.T) {
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.InstalledVersion = "v1.31.0"
+
+		version, err := NewURLResolver().ResolveLatestVersion(t.Context(), *binaryInfo)
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1.31.0", version)
+	})
+
+	t.Run("should handle request error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.LatestVersionURL = server.URL
+
+		_, err := NewURLResolver().ResolveLatestVersion(t.Context(), *binaryInfo)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), http.StatusText(http.StatusNotFound))
+	})
+
+	t.Run("should handle empty document", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		binaryInfo := newURLTestBinaryInfo("v1.31.0")
+		binaryInfo.LatestVersionURL = server.URL
+
+		_, err := NewURLResolver().ResolveLatestVersion(t.Context(), *binaryInfo)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no version found")
+	})
+
+	t.Run("should return provider error", func(t *testing.T) {
+		expected := errors.New("provider error")
+		resolver := NewURLResolver().WithVersionProvider(fakeVersionProvider{err: expected})
+
+		_, err := resolver.ResolveLatestVersion(t.Context(), *newURLTestBinaryInfo("v1.31.0"))
+
+		assert.ErrorIs(t, err, expected)
+	})
+}
+
+func TestURLName(t *testing.T) {
+	assert.Equal(t, URLResolverName, NewURLResolver().Name())
+}
@@ -0,0 +1,183 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+
+	operatorSpaces = regexp.MustCompile(`([<>=!~^]+)\s+`)
+	wildcardPart   = regexp.MustCompile(`(^|\.)[xX*](\.|$)`)
+)
+
+type comparator struct {
+	operator string
+	version  Version
+}
+
+// Constraint is a set of version ranges, e.g. "~1.2", ">=1.30,<1.32", "1.x" or "^1.2 || ^2.0",
+// comparators separated by a comma or a space must all match, groups separated by "||" are alternatives
+type Constraint struct {
+	raw    string
+	groups [][]comparator
+}
+
+// IsConstraint reports whether a requested version is a range rather than an exact tag
+func IsConstraint(requested string) bool {
+	return strings.ContainsAny(requested, "<>=~^*|, ") || wildcardPart.MatchString(requested)
+}
+
+func ParseConstraint(raw string) (Constraint, error) {
+	constraint := Constraint{raw: raw}
+	for _, group := range strings.Split(raw, "||") {
+		group = operatorSpaces.ReplaceAllString(strings.TrimSpace(group), "$1")
+		terms := strings.FieldsFunc(group, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(terms) == 0 {
+			return Constraint{}, fmt.Errorf("%w %q: empty range", ErrInvalidConstraint, raw)
+		}
+
+		comparators := make([]comparator, 0, len(terms))
+		for _, term := range terms {
+			parsed, err := parseTerm(term)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("%w %q: %w", ErrInvalidConstraint, raw, err)
+			}
+			comparators = append(comparators, parsed...)
+		}
+		constraint.groups = append(constraint.groups, comparators)
+	}
+	return constraint, nil
+}
+
+// bump returns the lowest version above every version starting with the given segments
+func bump(v Version, segments int) Version {
+	switch segments {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// parseTerm expands a single term into primitive comparators
+func parseTerm(term string) ([]comparator, error) {
+	operator := term[:len(term)-len(strings.TrimLeft(term, "<>=!~^"))]
+	v, segments, err := parsePartial(strings.TrimPrefix(term[len(operator):], "v"), true)
+	if err != nil {
+		return nil, err
+	}
+	v.Original = ""
+	if segments < 3 {
+		// a partial version cannot target a prerelease
+		v.Prerelease = ""
+	}
+
+	if segments == 0 {
+		switch operator {
+		case "", "=", "~", "^", ">=", "<=":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("operator %s needs a version", operator)
+	}
+
+	switch operator {
+	case "", "=":
+		if segments == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return []comparator{{">=", v}, {"<", bump(v, segments)}}, nil
+	case "~":
+		return []comparator{{">=", v}, {"<", bump(v, min(segments, 2))}}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || segments == 1:
+			return []comparator{{">=", v}, {"<", bump(v, 1)}}, nil
+		case v.Minor > 0 || segments == 2:
+			return []comparator{{">=", v}, {"<", bump(v, 2)}}, nil
+		}
+		return []comparator{{">=", v}, {"<", bump(v, 3)}}, nil
+	case ">":
+		if segments == 3 {
+			return []comparator{{">", v}}, nil
+		}
+		return []comparator{{">=", bump(v, segments)}}, nil
+	case ">=", "<", "!=":
+		return []comparator{{operator, v}}, nil
+	case "<=":
+		if segments == 3 {
+			return []comparator{{"<=", v}}, nil
+		}
+		return []comparator{{"<", bump(v, segments)}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", operator)
+}
+
+func (c comparator) match(v Version) bool {
+	result := v.Compare(c.version)
+	switch c.operator {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+	return false
+}
+
+// Match reports whether the version is inside the constraint, prereleases only match
+// a range explicitly naming a prerelease of the same version
+func (c Constraint) Match(v Version) bool {
+	for _, group := range c.groups {
+		if matchGroup(group, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGroup(group []comparator, v Version) bool {
+	allowPrerelease := v.Prerelease == ""
+	for _, comparator := range group {
+		if !comparator.match(v) {
+			return false
+		}
+		bound := comparator.version
+		if bound.Prerelease != "" && bound.Major == v.Major && bound.Minor == v.Minor && bound.Patch == v.Patch {
+			allowPrerelease = true
+		}
+	}
+	return allowPrerelease
+}
+
+// Highest returns the highest tag matching the constraint, tags which are not versions are ignored
+func (c Constraint) Highest(tags []string) (string, bool) {
+	var best Version
+	found := false
+	for _, tag := range tags {
+		v, err := Parse(tag)
+		if err != nil || !c.Match(v) {
+			continue
+		}
+		if !found || v.Compare(best) > 0 {
+			best = v
+			found = true
+		}
+	}
+	return best.Original, found
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
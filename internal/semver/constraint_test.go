@@ -0,0 +1,124 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsConstraint(t *testing.T) {
+	testCases := []struct {
+		requested string
+		expected  bool
+	}{
+		{requested: "latest", expected: false},
+		{requested: "v1.2.3", expected: false},
+		{requested: "1.2", expected: false},
+		{requested: "linux-x64", expected: false},
+		{requested: "~1.2", expected: true},
+		{requested: "^1.2", expected: true},
+		{requested: ">=1.30,<1.32", expected: true},
+		{requested: "1.x", expected: true},
+		{requested: "1.2.*", expected: true},
+		{requested: "*", expected: true},
+		{requested: "^1 || ^2", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.requested, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsConstraint(tc.requested))
+		})
+	}
+}
+
+func TestConstraintMatch(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		matching   []string
+		excluded   []string
+	}{
+		{constraint: "~1.2", matching: []string{"1.2.0", "v1.2.9"}, excluded: []string{"1.1.9", "1.3.0"}},
+		{constraint: "~1.2.3", matching: []string{"1.2.3", "1.2.9"}, excluded: []string{"1.2.2", "1.3.0"}},
+		{constraint: "~1", matching: []string{"1.0.0", "1.9.0"}, excluded: []string{"2.0.0"}},
+		{constraint: "^1.2", matching: []string{"1.2.0", "1.9.0"}, excluded: []string{"1.1.0", "2.0.0"}},
+		{constraint: "^0.2.3", matching: []string{"0.2.3", "0.2.9"}, excluded: []string{"0.3.0", "0.2.2"}},
+		{constraint: "^0.0.3", matching: []string{"0.0.3"}, excluded: []string{"0.0.4"}},
+		{
+			constraint: ">=1.30,<1.32",
+			matching:   []string{"1.30.0", "v1.31.4"},
+			excluded:   []string{"1.29.9", "1.32.0"},
+		},
+		{constraint: ">= 1.30 < 1.32", matching: []string{"1.31.0"}, excluded: []string{"1.32.0"}},
+		{constraint: "1.x", matching: []string{"1.0.0", "1.99.0"}, excluded: []string{"0.9.0", "2.0.0"}},
+		{constraint: "1.2.*", matching: []string{"1.2.0", "1.2.7"}, excluded: []string{"1.3.0"}},
+		{constraint: "1.2", matching: []string{"1.2.0", "1.2.7"}, excluded: []string{"1.3.0"}},
+		{constraint: "*", matching: []string{"0.0.1", "12.0.0"}, excluded: []string{"1.0.0-rc.1"}},
+		{constraint: ">1.2", matching: []string{"1.3.0"}, excluded: []string{"1.2.9"}},
+		{constraint: "<=1.2", matching: []string{"1.2.9"}, excluded: []string{"1.3.0"}},
+		{constraint: ">1.2.3", matching: []string{"1.2.4"}, excluded: []string{"1.2.3"}},
+		{constraint: "!=1.2.3", matching: []string{"1.2.4"}, excluded: []string{"1.2.3"}},
+		{constraint: "^1 || ^3", matching: []string{"1.5.0", "3.0.0"}, excluded: []string{"2.0.0"}},
+		{constraint: "~1.2", excluded: []string{"1.2.5-rc.1"}},
+		{constraint: ">=1.2.5-rc.1", matching: []string{"1.2.5-rc.2", "1.2.5"}, excluded: []string{"1.2.6-rc.1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tc.constraint)
+			require.NoError(t, err)
+
+			for _, tag := range tc.matching {
+				v, err := Parse(tag)
+				require.NoError(t, err)
+				assert.True(t, constraint.Match(v), "%s should match %s", tag, tc.constraint)
+			}
+			for _, tag := range tc.excluded {
+				v, err := Parse(tag)
+				require.NoError(t, err)
+				assert.False(t, constraint.Match(v), "%s should not match %s", tag, tc.constraint)
+			}
+		})
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	t.Run("should handle invalid constraints", func(t *testing.T) {
+		for _, raw := range []string{"", ">=", "~foo", "1.2 ||", "=>>1.2", ">*"} {
+			_, err := ParseConstraint(raw)
+
+			require.Error(t, err, raw)
+			assert.ErrorIs(t, err, ErrInvalidConstraint, raw)
+		}
+	})
+
+	t.Run("should keep the raw constraint", func(t *testing.T) {
+		constraint, err := ParseConstraint("~1.2")
+
+		require.NoError(t, err)
+		assert.Equal(t, "~1.2", constraint.String())
+	})
+}
+
+func TestHighest(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.2.10", "v1.2.9", "v1.3.0-rc.1", "v1.3.0", "nightly", "v2.0.0"}
+
+	t.Run("should return the highest matching tag", func(t *testing.T) {
+		constraint, err := ParseConstraint("~1.2")
+		require.NoError(t, err)
+
+		tag, ok := constraint.Highest(tags)
+
+		assert.True(t, ok)
+		assert.Equal(t, "v1.2.10", tag)
+	})
+
+	t.Run("should handle no match", func(t *testing.T) {
+		constraint, err := ParseConstraint("^3")
+		require.NoError(t, err)
+
+		_, ok := constraint.Highest(tags)
+
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,137 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidVersion = errors.New("invalid version")
+
+// Version is a semantic version parsed from a release tag, Original keeps the tag as published
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Original   string
+}
+
+// Parse reads a version from a tag, leading text like "v" or "tool-" and build metadata are ignored,
+// missing minor and patch numbers are zero and extra numeric segments are dropped
+func Parse(tag string) (Version, error) {
+	version, segments, err := parsePartial(tag, false)
+	if err != nil {
+		return Version{}, err
+	}
+	if segments == 0 {
+		return Version{}, fmt.Errorf("%w %s", ErrInvalidVersion, tag)
+	}
+	return version, nil
+}
+
+// parsePartial parses a version and returns how many numeric segments were given,
+// when allowed, wildcards (x, X, *) stop the parsing
+func parsePartial(tag string, wildcards bool) (Version, int, error) {
+	version := Version{Original: tag}
+	idx := strings.IndexFunc(tag, unicode.IsDigit)
+	if wildcards && tag != "" && isWildcard(tag[:1]) {
+		idx = 0
+	}
+	// a constraint names the version directly, without any leading text
+	if idx < 0 || wildcards && idx > 0 {
+		return version, 0, fmt.Errorf("%w %s", ErrInvalidVersion, tag)
+	}
+	rest, _, _ := strings.Cut(tag[idx:], "+")
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	segments := 0
+	for {
+		end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			if wildcards && len(rest) > 0 && isWildcard(rest[:1]) {
+				return version, segments, nil
+			}
+			break
+		}
+		number, err := strconv.Atoi(rest[:end])
+		if err != nil {
+			return version, 0, fmt.Errorf("%w %s: %w", ErrInvalidVersion, tag, err)
+		}
+		if segments < len(numbers) {
+			*numbers[segments] = number
+		}
+		segments++
+		rest = rest[end:]
+		if !strings.HasPrefix(rest, ".") || len(rest) < 2 ||
+			!(unicode.IsDigit(rune(rest[1])) || wildcards && isWildcard(rest[1:2])) {
+			break
+		}
+		rest = rest[1:]
+	}
+
+	version.Prerelease = strings.TrimLeft(rest, "-.")
+	return version, min(segments, len(numbers)), nil
+}
+
+func isWildcard(segment string) bool {
+	return segment == "x" || segment == "X" || segment == "*"
+}
+
+func (v Version) String() string {
+	if v.Prerelease != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.Prerelease)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 when v is lower, equal or greater than other,
+// a prerelease is lower than its release
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+func comparePrerelease(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNumber, aErr := strconv.Atoi(aParts[i])
+		bNumber, bErr := strconv.Atoi(bParts[i])
+		var result int
+		switch {
+		case aErr == nil && bErr == nil:
+			result = aNumber - bNumber
+		case aErr == nil:
+			result = -1
+		case bErr == nil:
+			result = 1
+		default:
+			result = strings.Compare(aParts[i], bParts[i])
+		}
+		if result != 0 {
+			return max(-1, min(1, result))
+		}
+	}
+	return max(-1, min(1, len(aParts)-len(bParts)))
+}
@@ -0,0 +1,81 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected Version
+	}{
+		{tag: "1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{tag: "v1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{tag: "V1.2", expected: Version{Major: 1, Minor: 2}},
+		{tag: "jq-1.7.1", expected: Version{Major: 1, Minor: 7, Patch: 1}},
+		{tag: "helm-docs-v1.14.2", expected: Version{Major: 1, Minor: 14, Patch: 2}},
+		{tag: "v2", expected: Version{Major: 2}},
+		{tag: "v1.2.3-rc.1", expected: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"}},
+		{tag: "1.2.3beta1", expected: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta1"}},
+		{tag: "v1.2.3+build.5", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{tag: "1.2.3.4", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tag, func(t *testing.T) {
+			got, err := Parse(tc.tag)
+
+			require.NoError(t, err)
+			tc.expected.Original = tc.tag
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	t.Run("should handle tag without version", func(t *testing.T) {
+		for _, tag := range []string{"", "latest", "nightly"} {
+			_, err := Parse(tag)
+
+			require.Error(t, err, tag)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+}
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.2.3", b: "1.2.3", expected: 0},
+		{a: "v1.2.3", b: "1.2.3", expected: 0},
+		{a: "1.2.3", b: "1.2.4", expected: -1},
+		{a: "1.10.0", b: "1.9.9", expected: 1},
+		{a: "2.0.0", b: "1.99.99", expected: 1},
+		{a: "1.2.3-rc.1", b: "1.2.3", expected: -1},
+		{a: "1.2.3-rc.2", b: "1.2.3-rc.10", expected: -1},
+		{a: "1.2.3-beta", b: "1.2.3-alpha", expected: 1},
+		{a: "1.2.3-rc", b: "1.2.3-rc.1", expected: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			a, err := Parse(tc.a)
+			require.NoError(t, err)
+			b, err := Parse(tc.b)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, a.Compare(b))
+			assert.Equal(t, -tc.expected, b.Compare(a))
+		})
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	v, err := Parse("v1.2-rc.1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2.0-rc.1", v.String())
+}
@@ -14,10 +14,16 @@ import (
 
 const StateFileName = "state.json"
 
+// ReadOnly makes Save release the lock without writing the state file, used by --dry-run
+var ReadOnly bool
+
 type State interface {
 	Load() error
+	Peek() error
 	Save() error
+	Unlock() error
 	UpdateEntrie(dto.BinaryInfo)
+	RemoveEntry(string)
 	Has(string) bool
 	Entry(string) (dto.BinaryInfo, bool)
 	Entries() map[string]dto.BinaryInfo
@@ -37,7 +43,11 @@ func NewState(path string) *LocalState {
 }
 
 func (l *LocalState) Load() error {
-	file, err := os.OpenFile(l.path, os.O_CREATE, 0o644)
+	flag := os.O_CREATE
+	if ReadOnly {
+		flag = os.O_RDONLY
+	}
+	file, err := os.OpenFile(l.path, flag, 0o644)
 	if err != nil {
 		return err
 	}
@@ -48,25 +58,62 @@ func (l *LocalState) Load() error {
 		return errors.New("another install/update command is currently running, try again later")
 	}
 
+	if err := l.decode(file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return nil
+}
+
+// Peek reads the state without taking the lock, for commands that never write it and must not fail
+// while an install is running. Save replaces the file atomically so a peek never reads a partial state
+func (l *LocalState) Peek() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return l.decode(file)
+}
+
+func (l *LocalState) decode(r io.Reader) error {
 	var binaries []dto.BinaryInfo
-	err = json.NewDecoder(file).Decode(&binaries)
+	err := json.NewDecoder(r).Decode(&binaries)
 	if err != nil && !errors.Is(err, io.EOF) {
-		l.file.Close()
 		return err
 	}
 
 	for _, binaryInfo := range binaries {
-		l.Binaries[binaryInfo.FullName] = binaryInfo
+		l.Binaries[binaryInfo.FullName] = migrate(binaryInfo)
 	}
-
 	return nil
 }
 
+// migrate fills fields introduced after the entry was written,
+// older states only know a single installed version
+func migrate(binaryInfo dto.BinaryInfo) dto.BinaryInfo {
+	if binaryInfo.ActiveVersion == "" {
+		binaryInfo.ActiveVersion = binaryInfo.InstalledVersion
+	}
+	if len(binaryInfo.Versions) == 0 && binaryInfo.InstalledVersion != "" {
+		binaryInfo.Versions = []dto.VersionInfo{{Version: binaryInfo.InstalledVersion}}
+	}
+	return binaryInfo
+}
+
 func (l *LocalState) UpdateEntrie(binaryInfo dto.BinaryInfo) {
 	l.Binaries[binaryInfo.FullName] = binaryInfo
 }
 
+func (l *LocalState) RemoveEntry(binaryName string) {
+	delete(l.Binaries, binaryName)
+}
+
 func (l *LocalState) Save() error {
+	if ReadOnly {
+		return l.Unlock()
+	}
+
 	tmpPath := l.path + ".tmp"
 	file, err := os.Create(filepath.Clean(tmpPath))
 	if err != nil {
@@ -96,11 +143,18 @@ func (l *LocalState) Save() error {
 		return err
 	}
 
-	if l.file != nil {
-		_ = syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
-		return l.file.Close()
+	return l.Unlock()
+}
+
+// Unlock releases the state lock without writing, it is a no-op when the state is not loaded
+func (l *LocalState) Unlock() error {
+	if l.file == nil {
+		return nil
 	}
-	return nil
+	_ = syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	err := l.file.Close()
+	l.file = nil
+	return err
 }
 
 func (l *LocalState) Has(binaryName string) bool {
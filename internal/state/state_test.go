@@ -48,6 +48,24 @@ func TestStateHelpers(t *testing.T) {
 	assert.False(t, ko)
 }
 
+func TestRemoveEntry(t *testing.T) {
+	t.Run("should remove the binary from state", func(t *testing.T) {
+		state := NewState("foo.json")
+		name, version := testBinaryName, testBinaryVersion
+		binaryInfo := dto.BinaryInfo{FullName: name, Version: version, Name: name, Owner: name, InstalledVersion: version}
+
+		state.UpdateEntrie(binaryInfo)
+		require.True(t, state.Has(name))
+
+		state.RemoveEntry(name)
+		assert.False(t, state.Has(name))
+
+		// removing an unknown entry is a no-op
+		state.RemoveEntry("notFound")
+		assert.Empty(t, state.Binaries)
+	})
+}
+
 func TestLoad(t *testing.T) {
 	t.Run("should create the file when not present and handle empty state", func(t *testing.T) {
 		path := t.TempDir()
@@ -96,6 +114,10 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, name, state.Binaries[name].Owner)
 		assert.Equal(t, version, state.Binaries[name].Version)
 		assert.Equal(t, version, state.Binaries[name].InstalledVersion)
+		assert.Equal(t, version, state.Binaries[name].ActiveVersion,
+			"active version should default to installed version")
+		require.Len(t, state.Binaries[name].Versions, 1, "installed version should be tracked")
+		assert.Equal(t, version, state.Binaries[name].Versions[0].Version)
 	})
 
 	t.Run("should return error on bad json", func(t *testing.T) {
@@ -122,6 +144,34 @@ func TestLoad(t *testing.T) {
 	})
 }
 
+func TestPeek(t *testing.T) {
+	t.Run("should read the state while it is locked", func(t *testing.T) {
+		statePath := filepath.Join(t.TempDir(), "state.json")
+		data := []dto.BinaryInfo{{FullName: testBinaryName, Name: testBinaryName, InstalledVersion: testBinaryVersion}}
+		content, err := json.Marshal(data)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(statePath, content, 0o600))
+		locked := NewState(statePath)
+		require.NoError(t, locked.Load())
+		defer locked.Unlock()
+		state := NewState(statePath)
+
+		err = state.Peek()
+
+		require.NoError(t, err)
+		assert.Equal(t, testBinaryVersion, state.Binaries[testBinaryName].ActiveVersion)
+	})
+
+	t.Run("should handle missing state file", func(t *testing.T) {
+		state := NewState(filepath.Join(t.TempDir(), "state.json"))
+
+		err := state.Peek()
+
+		require.ErrorIs(t, err, os.ErrNotExist)
+		assert.NoFileExists(t, filepath.Join(filepath.Dir(state.path), "state.json"), "peek should not create it")
+	})
+}
+
 func TestSave(t *testing.T) {
 	t.Run("should save cache", func(t *testing.T) {
 		path := t.TempDir()
@@ -162,6 +212,33 @@ func TestSave(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("should not write the state when read only", func(t *testing.T) {
+		ReadOnly = true
+		defer func() { ReadOnly = false }()
+		path := t.TempDir()
+		statePath := filepath.Join(path, "state.json")
+		state := NewState(statePath)
+		name, version := testBinaryName, testBinaryVersion
+
+		err := state.Load()
+		require.ErrorIs(t, err, os.ErrNotExist, "read only load should not create the state")
+
+		state.UpdateEntrie(dto.BinaryInfo{FullName: name, Name: name, Owner: name, InstalledVersion: version})
+		err = state.Save()
+		require.NoError(t, err)
+		assert.NoFileExists(t, statePath)
+
+		require.NoError(t, os.WriteFile(statePath, []byte("[]"), 0o600))
+		require.NoError(t, state.Load())
+		require.NoError(t, state.Save())
+		require.NoError(t, state.Load(), "lock should be released")
+		require.NoError(t, state.Save())
+
+		input, err := os.ReadFile(statePath)
+		require.NoError(t, err)
+		assert.Equal(t, "[]", string(input))
+	})
+
 	t.Run("should return an error on invalid path", func(t *testing.T) {
 		path := t.TempDir()
 		invalidPath := filepath.Join(path, "nonexistent", "state.json")
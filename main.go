@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,6 +10,13 @@ import (
 
 func main() {
 	err := cmd.Execute()
+	var exitErr *cmd.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Err != nil {
+			fmt.Printf("\nError: %v\n", exitErr.Err)
+		}
+		os.Exit(exitErr.Code)
+	}
 	if err != nil {
 		fmt.Printf("\nError: %v\n", err)
 		os.Exit(1)